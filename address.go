@@ -0,0 +1,520 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Weights of each address component when computing confidence
+const (
+	weightSubDistrict = 0.35
+	weightDistrict    = 0.25
+	weightProvince    = 0.2
+	weightZipCode     = 0.2
+)
+
+// maxAddressCandidates caps the ranked candidates returned by the parser
+const maxAddressCandidates = 5
+
+var (
+	// zipCodePattern matches a standalone five-digit zip code
+	zipCodePattern = regexp.MustCompile(`(?:^|[^\d/])(\d{5})(?:[^\d]|$)`)
+
+	// Prefixed component patterns, e.g. "ต.หนองปรือ", "อำเภอ บางละมุง", "จ.ชลบุรี"
+	subDistrictPattern = regexp.MustCompile(`(?:ตำบล|แขวง|ต\.)\s*([^\s,]+)`)
+	districtPattern    = regexp.MustCompile(`(?:กิ่งอำเภอ|อำเภอ|เขต|อ\.)\s*([^\s,]+)`)
+	provincePattern    = regexp.MustCompile(`(?:จังหวัด|จ\.)\s*([^\s,]+)`)
+)
+
+// provinceAliases maps common abbreviations to the stored province name
+var provinceAliases = map[string]string{
+	"กทม":      "กรุงเทพมหานคร",
+	"กทม.":     "กรุงเทพมหานคร",
+	"กรุงเทพ":  "กรุงเทพมหานคร",
+	"กรุงเทพฯ": "กรุงเทพมหานคร",
+	"bkk":      "กรุงเทพมหานคร",
+	"bangkok":  "กรุงเทพมหานคร",
+}
+
+// addressComponents holds the raw name fragments extracted from an address
+type addressComponents struct {
+	subDistrict string
+	district    string
+	province    string
+	zipCode     int
+}
+
+// extractAddressComponents pulls the zip code and named components out of a
+// free-form address, returning them together with the leftover text
+func (ds *DataService) extractAddressComponents(input string) (addressComponents, string) {
+	var components addressComponents
+	remainder := " " + input + " "
+
+	// Zip code, preferring the last five-digit number in the address
+	if matches := zipCodePattern.FindAllStringSubmatchIndex(remainder, -1); len(matches) > 0 {
+		last := matches[len(matches)-1]
+		zip := remainder[last[2]:last[3]]
+		components.zipCode, _ = strconv.Atoi(zip)
+		remainder = remainder[:last[2]] + remainder[last[3]:]
+	}
+
+	// Components introduced by an administrative prefix
+	extract := func(pattern *regexp.Regexp) string {
+		loc := pattern.FindStringSubmatchIndex(remainder)
+		if loc == nil {
+			return ""
+		}
+		name := remainder[loc[2]:loc[3]]
+		remainder = remainder[:loc[0]] + " " + remainder[loc[1]:]
+		return name
+	}
+	components.subDistrict = extract(subDistrictPattern)
+	components.province = extract(provincePattern)
+	components.district = extract(districtPattern)
+
+	// Unprefixed components: comma-separated segments first (for romanized
+	// addresses), then whitespace-separated tokens, from the end of the address
+	claim := func(fragments []string) {
+		for i := len(fragments) - 1; i >= 0; i-- {
+			fragment := strings.TrimSpace(fragments[i])
+			if fragment == "" || strings.ContainsAny(fragment, "0123456789") {
+				continue
+			}
+
+			level := ds.unclaimedLevel(fragment, components)
+			switch level {
+			case LevelProvince:
+				components.province = fragment
+			case LevelDistrict:
+				components.district = fragment
+			case LevelSubDistrict:
+				components.subDistrict = fragment
+			default:
+				continue
+			}
+			remainder = strings.Replace(remainder, fragment, " ", 1)
+		}
+	}
+	claim(strings.Split(remainder, ","))
+	claim(strings.Fields(strings.ReplaceAll(remainder, ",", " ")))
+
+	return components, strings.Join(strings.Fields(strings.Trim(remainder, " ,")), " ")
+}
+
+// unclaimedLevel returns the broadest level not yet filled whose names exactly match
+// the fragment, searching from province down as addresses usually end with the province
+func (ds *DataService) unclaimedLevel(fragment string, components addressComponents) string {
+	if alias, ok := provinceAliases[strings.ToLower(fragment)]; ok {
+		fragment = alias
+	}
+
+	matched := make(map[string]bool)
+	for _, match := range ds.names.lookup(fragment) {
+		if match.exact {
+			matched[match.ref.level] = true
+		}
+	}
+
+	switch {
+	case matched[LevelProvince] && components.province == "":
+		return LevelProvince
+	case matched[LevelDistrict] && components.district == "":
+		return LevelDistrict
+	case matched[LevelSubDistrict] && components.subDistrict == "":
+		return LevelSubDistrict
+	}
+	return ""
+}
+
+// ParseAddress resolves a free-form Thai address into ranked candidates
+func (ds *DataService) ParseAddress(input string) ParsedAddress {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	components, remainder := ds.extractAddressComponents(input)
+	if alias, ok := provinceAliases[strings.ToLower(components.province)]; ok {
+		components.province = alias
+	}
+
+	result := ParsedAddress{
+		Input: input,
+		Components: AddressComponents{
+			SubDistrict: components.subDistrict,
+			District:    components.district,
+			Province:    components.province,
+			ZipCode:     components.zipCode,
+		},
+		Remainder:  remainder,
+		Candidates: ds.addressCandidates(components),
+	}
+
+	// A single best candidate is a match; ties are left for the caller to pick
+	if len(result.Candidates) > 0 {
+		best := result.Candidates[0]
+		result.Ambiguous = len(result.Candidates) > 1 && result.Candidates[1].Confidence == best.Confidence
+		if !result.Ambiguous {
+			result.Match = &best
+		}
+	}
+
+	return result
+}
+
+// addressCandidates scores every sub-district that could match the components
+func (ds *DataService) addressCandidates(components addressComponents) []AddressCandidate {
+	candidates := make(map[int]SubDistrict)
+	addAll := func(subDistricts []SubDistrict) {
+		for _, subDistrict := range subDistricts {
+			candidates[subDistrict.ID] = subDistrict
+		}
+	}
+
+	if components.zipCode != 0 {
+		addAll(ds.subDistrictsByZipCode[components.zipCode])
+	}
+	if components.subDistrict != "" {
+		for _, match := range ds.names.lookup(components.subDistrict) {
+			if match.ref.level == LevelSubDistrict && match.prefix {
+				candidates[match.ref.id] = ds.subDistrictMap[match.ref.id]
+			}
+		}
+	}
+	if len(candidates) == 0 && components.district != "" {
+		for _, match := range ds.names.lookup(components.district) {
+			if match.ref.level == LevelDistrict && match.exact {
+				addAll(ds.subDistrictsByDistrict[match.ref.id])
+			}
+		}
+	}
+
+	// Fall back to every sub-district of the province when nothing narrower
+	// resolves; the province alone says little, so confidence is scaled down
+	provinceOnly := false
+	if len(candidates) == 0 && components.province != "" {
+		for _, match := range ds.names.lookup(components.province) {
+			if match.ref.level == LevelProvince && match.exact {
+				provinceOnly = true
+				for _, district := range ds.districtsByProvince[match.ref.id] {
+					addAll(ds.subDistrictsByDistrict[district.ID])
+				}
+			}
+		}
+	}
+
+	results := make([]AddressCandidate, 0, len(candidates))
+	for _, subDistrict := range candidates {
+		district := ds.districtMap[subDistrict.DistrictID]
+		province := ds.provinceMap[district.ProvinceID]
+
+		confidence := scoreAddress(components, subDistrict, district, province)
+		if confidence == 0 {
+			continue
+		}
+		if provinceOnly {
+			confidence *= weightProvince
+		}
+
+		results = append(results, AddressCandidate{
+			SubDistrictID: subDistrict.ID,
+			DistrictID:    district.ID,
+			ProvinceID:    province.ID,
+			ZipCode:       subDistrict.ZipCode,
+			Display:       displayNameTH(&subDistrict, &district, &province),
+			DisplayEN:     displayNameEN(&subDistrict, &district, &province),
+			Confidence:    roundScore(confidence),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		return results[i].SubDistrictID < results[j].SubDistrictID
+	})
+
+	if len(results) > maxAddressCandidates {
+		results = results[:maxAddressCandidates]
+	}
+	return results
+}
+
+// scoreAddress returns the weighted share of the extracted components that
+// agree with a candidate, between 0 and 1
+func scoreAddress(components addressComponents, subDistrict SubDistrict, district District, province Province) float64 {
+	var score, total float64
+
+	if components.subDistrict != "" {
+		total += weightSubDistrict
+		score += weightSubDistrict * nameSimilarity(components.subDistrict, LevelSubDistrict, subDistrict.NameTH, subDistrict.NameEN)
+	}
+	if components.district != "" {
+		total += weightDistrict
+		score += weightDistrict * nameSimilarity(components.district, LevelDistrict, district.NameTH, district.NameEN)
+	}
+	if components.province != "" {
+		total += weightProvince
+		score += weightProvince * nameSimilarity(components.province, LevelProvince, province.NameTH, province.NameEN)
+	}
+	if components.zipCode != 0 {
+		total += weightZipCode
+		if components.zipCode == subDistrict.ZipCode {
+			score += weightZipCode
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return score / total
+}
+
+// nameSimilarity is 1 for a normalized exact match against the names of an
+// entity at the given level, otherwise the fuzzy score when it clears the
+// fuzzy threshold
+func nameSimilarity(fragment, level, nameTH, nameEN string) float64 {
+	normalized := normalizeSearchText(fragment, false)
+	if normalized == normalizeName(nameTH, level, false) || normalized == normalizeName(nameEN, level, false) {
+		return 1
+	}
+
+	if score := bestFuzzyScore(fragment, level, nameEN, nameTH, false); score >= fuzzyThreshold {
+		return score
+	}
+	return 0
+}
+
+// addressQuery describes the components supplied to address validation
+type addressQuery struct {
+	req AddressValidateRequest
+}
+
+// hasSubDistrict reports whether a sub-district was supplied
+func (q addressQuery) hasSubDistrict() bool {
+	return q.req.SubDistrictID != 0 || q.req.SubDistrict != ""
+}
+
+// hasDistrict reports whether a district was supplied
+func (q addressQuery) hasDistrict() bool {
+	return q.req.DistrictID != 0 || q.req.District != ""
+}
+
+// hasProvince reports whether a province was supplied
+func (q addressQuery) hasProvince() bool {
+	return q.req.ProvinceID != 0 || q.req.Province != ""
+}
+
+// matchesSubDistrict reports whether the supplied sub-district refers to s
+func (q addressQuery) matchesSubDistrict(s SubDistrict) bool {
+	if q.req.SubDistrictID != 0 {
+		return q.req.SubDistrictID == s.ID
+	}
+	return nameSimilarity(q.req.SubDistrict, LevelSubDistrict, s.NameTH, s.NameEN) == 1
+}
+
+// matchesDistrict reports whether the supplied district refers to d
+func (q addressQuery) matchesDistrict(d District) bool {
+	if q.req.DistrictID != 0 {
+		return q.req.DistrictID == d.ID
+	}
+	return nameSimilarity(q.req.District, LevelDistrict, d.NameTH, d.NameEN) == 1
+}
+
+// matchesProvince reports whether the supplied province refers to p
+func (q addressQuery) matchesProvince(p Province) bool {
+	if q.req.ProvinceID != 0 {
+		return q.req.ProvinceID == p.ID
+	}
+	name := q.req.Province
+	if alias, ok := provinceAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	return nameSimilarity(name, LevelProvince, p.NameTH, p.NameEN) == 1
+}
+
+// mismatches counts the supplied components that disagree with a tuple
+func (q addressQuery) mismatches(s SubDistrict, d District, p Province) int {
+	count := 0
+	if q.hasSubDistrict() && !q.matchesSubDistrict(s) {
+		count++
+	}
+	if q.hasDistrict() && !q.matchesDistrict(d) {
+		count++
+	}
+	if q.hasProvince() && !q.matchesProvince(p) {
+		count++
+	}
+	if q.req.ZipCode != 0 && q.req.ZipCode != s.ZipCode {
+		count++
+	}
+	return count
+}
+
+// ValidateAddress checks a (sub-district, district, province, zip) tuple for
+// consistency and suggests a correction when exactly one component is wrong
+func (ds *DataService) ValidateAddress(req AddressValidateRequest) AddressValidation {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	q := addressQuery{req: req}
+	result := AddressValidation{Errors: make([]FieldError, 0)}
+
+	// Resolve supplied components to the entities they may refer to
+	var subDistricts []SubDistrict
+	var districts []District
+	var provinces []Province
+	if q.hasSubDistrict() {
+		subDistricts = ds.resolveSubDistricts(q)
+		if len(subDistricts) == 0 {
+			result.Errors = append(result.Errors, FieldError{Field: "subdistrict", Message: "Sub-district not found"})
+		}
+	}
+	if q.hasDistrict() {
+		districts = ds.resolveDistricts(q)
+		if len(districts) == 0 {
+			result.Errors = append(result.Errors, FieldError{Field: "district", Message: "District not found"})
+		}
+	}
+	if q.hasProvince() {
+		for _, province := range ds.provinces {
+			if q.matchesProvince(province) {
+				provinces = append(provinces, province)
+			}
+		}
+		if len(provinces) == 0 {
+			result.Errors = append(result.Errors, FieldError{Field: "province", Message: "Province not found"})
+		}
+	}
+	if req.ZipCode != 0 && len(ds.subDistrictsByZipCode[req.ZipCode]) == 0 {
+		result.Errors = append(result.Errors, FieldError{Field: "zip_code", Message: "Zip code not found"})
+	}
+
+	// Relationship checks between components that were found
+	if len(subDistricts) > 0 && len(districts) > 0 && !anySubDistrict(subDistricts, func(s SubDistrict) bool {
+		return q.matchesDistrict(ds.districtMap[s.DistrictID])
+	}) {
+		result.Errors = append(result.Errors, FieldError{Field: "subdistrict", Message: "Sub-district does not belong to the district"})
+	}
+	if len(districts) > 0 && len(provinces) > 0 && !anyDistrict(districts, func(d District) bool {
+		return q.matchesProvince(ds.provinceMap[d.ProvinceID])
+	}) {
+		result.Errors = append(result.Errors, FieldError{Field: "district", Message: "District does not belong to the province"})
+	}
+	if len(subDistricts) > 0 && len(districts) == 0 && len(provinces) > 0 && !anySubDistrict(subDistricts, func(s SubDistrict) bool {
+		return q.matchesProvince(ds.provinceMap[ds.districtMap[s.DistrictID].ProvinceID])
+	}) {
+		result.Errors = append(result.Errors, FieldError{Field: "subdistrict", Message: "Sub-district does not belong to the province"})
+	}
+	if req.ZipCode != 0 && len(ds.subDistrictsByZipCode[req.ZipCode]) > 0 {
+		zipMatches := func(s SubDistrict) bool { return s.ZipCode == req.ZipCode }
+		switch {
+		case len(subDistricts) > 0 && !anySubDistrict(subDistricts, zipMatches):
+			result.Errors = append(result.Errors, FieldError{Field: "zip_code", Message: "Zip code does not match the sub-district"})
+		case len(subDistricts) == 0 && len(districts) > 0 && !anyDistrict(districts, func(d District) bool {
+			return anySubDistrict(ds.subDistrictsByDistrict[d.ID], zipMatches)
+		}):
+			result.Errors = append(result.Errors, FieldError{Field: "zip_code", Message: "Zip code does not match the district"})
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	if result.Valid {
+		return result
+	}
+
+	// Suggest a correction when a single tuple differs in only one component
+	candidates := make(map[int]SubDistrict)
+	for _, subDistrict := range subDistricts {
+		candidates[subDistrict.ID] = subDistrict
+	}
+	for _, district := range districts {
+		for _, subDistrict := range ds.subDistrictsByDistrict[district.ID] {
+			candidates[subDistrict.ID] = subDistrict
+		}
+	}
+	for _, subDistrict := range ds.subDistrictsByZipCode[req.ZipCode] {
+		candidates[subDistrict.ID] = subDistrict
+	}
+
+	var suggestion *AddressCandidate
+	for _, subDistrict := range candidates {
+		district := ds.districtMap[subDistrict.DistrictID]
+		province := ds.provinceMap[district.ProvinceID]
+		if q.mismatches(subDistrict, district, province) != 1 {
+			continue
+		}
+		if suggestion != nil {
+			// More than one single-fix tuple, so the correction is ambiguous
+			return result
+		}
+		suggestion = &AddressCandidate{
+			SubDistrictID: subDistrict.ID,
+			DistrictID:    district.ID,
+			ProvinceID:    province.ID,
+			ZipCode:       subDistrict.ZipCode,
+			Display:       displayNameTH(&subDistrict, &district, &province),
+			DisplayEN:     displayNameEN(&subDistrict, &district, &province),
+			Confidence:    1,
+		}
+	}
+	result.Suggestion = suggestion
+
+	return result
+}
+
+// resolveSubDistricts returns the sub-districts the supplied ID or name may refer to
+func (ds *DataService) resolveSubDistricts(q addressQuery) []SubDistrict {
+	if q.req.SubDistrictID != 0 {
+		if subDistrict, exists := ds.subDistrictMap[q.req.SubDistrictID]; exists {
+			return []SubDistrict{subDistrict}
+		}
+		return nil
+	}
+
+	var results []SubDistrict
+	for _, match := range ds.names.lookup(q.req.SubDistrict) {
+		if match.exact && match.ref.level == LevelSubDistrict {
+			results = append(results, ds.subDistrictMap[match.ref.id])
+		}
+	}
+	return results
+}
+
+// resolveDistricts returns the districts the supplied ID or name may refer to
+func (ds *DataService) resolveDistricts(q addressQuery) []District {
+	if q.req.DistrictID != 0 {
+		if district, exists := ds.districtMap[q.req.DistrictID]; exists {
+			return []District{district}
+		}
+		return nil
+	}
+
+	var results []District
+	for _, match := range ds.names.lookup(q.req.District) {
+		if match.exact && match.ref.level == LevelDistrict {
+			results = append(results, ds.districtMap[match.ref.id])
+		}
+	}
+	return results
+}
+
+// anySubDistrict reports whether any sub-district satisfies fn
+func anySubDistrict(subDistricts []SubDistrict, fn func(SubDistrict) bool) bool {
+	for _, subDistrict := range subDistricts {
+		if fn(subDistrict) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyDistrict reports whether any district satisfies fn
+func anyDistrict(districts []District, fn func(District) bool) bool {
+	for _, district := range districts {
+		if fn(district) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminMiddleware requires the admin token as a bearer token. Admin routes
+// are disabled when no token is configured.
+func AdminMiddleware(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return c.Status(403).JSON(APIResponse{
+				Status: "error",
+				Error:  "Admin endpoints are disabled; set ADMIN_TOKEN to enable them",
+			})
+		}
+
+		given, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return c.Status(401).JSON(APIResponse{
+				Status: "error",
+				Error:  "Invalid or missing admin token",
+			})
+		}
+
+		return c.Next()
+	}
+}
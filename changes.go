@@ -0,0 +1,105 @@
+package main
+
+import (
+	"time"
+)
+
+// LevelChanges lists the records of one level that changed since a point in time
+type LevelChanges[T any] struct {
+	Created []T             `json:"created"`
+	Updated []T             `json:"updated"`
+	Deleted []DeletedRecord `json:"deleted"`
+}
+
+// ChangeSet lists the changes across all levels since a point in time.
+// Watermark is the since value to send on the next call.
+type ChangeSet struct {
+	Since        *time.Time                `json:"since"`
+	Watermark    time.Time                 `json:"watermark"`
+	Geographies  LevelChanges[Geography]   `json:"geographies"`
+	Provinces    LevelChanges[Province]    `json:"provinces"`
+	Districts    LevelChanges[District]    `json:"districts"`
+	SubDistricts LevelChanges[SubDistrict] `json:"subdistricts"`
+}
+
+// newLevelChanges creates a LevelChanges with empty lists
+func newLevelChanges[T any]() LevelChanges[T] {
+	return LevelChanges[T]{
+		Created: make([]T, 0),
+		Updated: make([]T, 0),
+		Deleted: make([]DeletedRecord, 0),
+	}
+}
+
+// add classifies a record as created, updated or deleted after since.
+// deletedAt is set for deleted records, including the children of deleted
+// records; records deleted before since are left out.
+func (l *LevelChanges[T]) add(level string, id int, record T, createdAt, updatedAt time.Time, deletedAt *time.Time, since time.Time) {
+	switch {
+	case deletedAt != nil:
+		if deletedAt.After(since) {
+			l.Deleted = append(l.Deleted, DeletedRecord{Level: level, ID: id, DeletedAt: *deletedAt})
+		}
+	case createdAt.After(since):
+		l.Created = append(l.Created, record)
+	case updatedAt.After(since):
+		l.Updated = append(l.Updated, record)
+	}
+}
+
+// GetChanges returns the records created, updated or deleted after since; a
+// zero since returns every live record as created. Geographies carry no
+// timestamps, so they are only returned in full syncs. The watermark is the
+// latest timestamp in the data, so changes loaded later with older
+// timestamps than the server clock are not skipped.
+func (ds *DataService) GetChanges(since time.Time) ChangeSet {
+	current := ds.current()
+	all := current.withDeletedRecords
+
+	changes := ChangeSet{
+		Watermark:    since,
+		Geographies:  newLevelChanges[Geography](),
+		Provinces:    newLevelChanges[Province](),
+		Districts:    newLevelChanges[District](),
+		SubDistricts: newLevelChanges[SubDistrict](),
+	}
+	if !since.IsZero() {
+		changes.Since = &since
+	}
+
+	observe := func(times ...time.Time) {
+		for _, t := range times {
+			if t.After(changes.Watermark) {
+				changes.Watermark = t
+			}
+		}
+	}
+	deletedAt := func(level string, id int) *time.Time {
+		if t, deleted := current.deleted[nameRef{level: level, id: id}]; deleted {
+			observe(t)
+			return &t
+		}
+		return nil
+	}
+
+	if since.IsZero() {
+		changes.Geographies.Created = append(changes.Geographies.Created, all.geographies...)
+	}
+	for _, province := range all.provinces {
+		observe(province.CreatedAt, province.UpdatedAt)
+		changes.Provinces.add(LevelProvince, province.ID, province, province.CreatedAt, province.UpdatedAt,
+			deletedAt(LevelProvince, province.ID), since)
+	}
+	for _, district := range all.districts {
+		observe(district.CreatedAt, district.UpdatedAt)
+		changes.Districts.add(LevelDistrict, district.ID, district, district.CreatedAt, district.UpdatedAt,
+			deletedAt(LevelDistrict, district.ID), since)
+	}
+	for _, subDistrict := range all.subDistricts {
+		observe(subDistrict.CreatedAt, subDistrict.UpdatedAt)
+		changes.SubDistricts.add(LevelSubDistrict, subDistrict.ID, subDistrict, subDistrict.CreatedAt, subDistrict.UpdatedAt,
+			deletedAt(LevelSubDistrict, subDistrict.ID), since)
+	}
+
+	return changes
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paginationArgs are query parameters that select a page rather than filter results
+var paginationArgs = map[string]bool{
+	"page":   true,
+	"limit":  true,
+	"cursor": true,
+}
+
+// cursorLocalsKey is the fiber locals key holding the decoded cursor
+const cursorLocalsKey = "cursor"
+
+// pageCursor is the decoded form of an opaque pagination cursor
+type pageCursor struct {
+	Path    string            `json:"p"`
+	AfterID int               `json:"a"`
+	Limit   int               `json:"l,omitempty"`
+	Filters map[string]string `json:"f,omitempty"`
+}
+
+// encodeCursor serializes a cursor into an opaque URL-safe token
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor token
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errors.New("Invalid cursor parameter")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errors.New("Invalid cursor parameter")
+	}
+	return cursor, nil
+}
+
+// versionFilter is the filter key, and query parameter, naming the dataset version
+const versionFilter = "version"
+
+// activeFilters returns the request's query parameters that filter results,
+// including a dataset version named by header
+func activeFilters(c *fiber.Ctx) map[string]string {
+	filters := make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if !paginationArgs[string(key)] {
+			filters[string(key)] = string(value)
+		}
+	})
+	if version := c.Get(datasetVersionHeader); version != "" && filters[versionFilter] == "" {
+		filters[versionFilter] = version
+	}
+	return filters
+}
+
+// CursorMiddleware decodes the cursor parameter of list endpoints. Filters,
+// the dataset version and the page size stored in the cursor are restored onto
+// the request so clients may send the cursor alone; an explicit limit still
+// overrides the page size. A cursor issued for other filters, another dataset
+// version or another endpoint is rejected.
+func CursorMiddleware(c *fiber.Ctx) error {
+	token := c.Query("cursor")
+	if token == "" {
+		return c.Next()
+	}
+
+	cursor, err := decodeCursor(token)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	// Requests that do not name a dataset version continue in the cursor's
+	filters := activeFilters(c)
+	expected := cursor.Filters
+	if _, named := filters[versionFilter]; !named {
+		expected = maps.Clone(cursor.Filters)
+		delete(expected, versionFilter)
+	}
+	if cursor.Path != c.Path() || (len(filters) > 0 && !maps.Equal(filters, expected)) {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "cursor does not match the current request filters",
+		})
+	}
+
+	// Restore filters in a stable order
+	keys := make([]string, 0, len(cursor.Filters))
+	for key := range cursor.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.Context().QueryArgs().Set(key, cursor.Filters[key])
+	}
+	if cursor.Limit > 0 && c.Query("limit") == "" {
+		c.Context().QueryArgs().SetUint("limit", cursor.Limit)
+	}
+
+	c.Locals(cursorLocalsKey, cursor)
+	return c.Next()
+}
+
+// keyed is implemented by list items that can be addressed by a cursor
+type keyed interface {
+	key() int
+}
+
+func (g Geography) key() int   { return g.ID }
+func (p Province) key() int    { return p.ID }
+func (d District) key() int    { return d.ID }
+func (s SubDistrict) key() int { return s.ID }
+
+// cursorStart returns the index of the first item following the item with
+// the given key. Lists ordered by ID resume after the key even if that item
+// has since been removed; other orderings require the item to still be present.
+func cursorStart[T keyed](v []T, afterID int) (int, error) {
+	for i, item := range v {
+		if item.key() == afterID {
+			return i + 1, nil
+		}
+	}
+
+	ascending := sort.SliceIsSorted(v, func(i, j int) bool {
+		return v[i].key() < v[j].key()
+	})
+	if !ascending {
+		return 0, errors.New("cursor is no longer valid for this result set")
+	}
+	return sort.Search(len(v), func(i int) bool {
+		return v[i].key() > afterID
+	}), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+)
+
+// levelNames are the display names of each level in messages
+var levelNames = map[string]string{
+	LevelProvince:    "Province",
+	LevelDistrict:    "District",
+	LevelSubDistrict: "Sub-district",
+}
+
+// withoutDeleted returns a dataset holding only live records, leaving out
+// records with DeletedAt set and the children of deleted records. It returns
+// the dataset itself if nothing is deleted. The result is not yet indexed.
+func (d *dataset) withoutDeleted() *dataset {
+	live := newDataset(d.loadedAt, d.checksums)
+	live.geographies = d.geographies
+	live.withDeletedRecords = d
+	d.withDeletedRecords = d
+
+	for _, province := range d.provinces {
+		if province.DeletedAt != nil {
+			live.deleted[nameRef{level: LevelProvince, id: province.ID}] = *province.DeletedAt
+			continue
+		}
+		live.provinces = append(live.provinces, province)
+	}
+
+	for _, district := range d.districts {
+		deletedAt, parentDeleted := live.deleted[nameRef{level: LevelProvince, id: district.ProvinceID}]
+		if district.DeletedAt != nil {
+			deletedAt, parentDeleted = *district.DeletedAt, true
+		}
+		if parentDeleted {
+			live.deleted[nameRef{level: LevelDistrict, id: district.ID}] = deletedAt
+			continue
+		}
+		live.districts = append(live.districts, district)
+	}
+
+	for _, subDistrict := range d.subDistricts {
+		deletedAt, parentDeleted := live.deleted[nameRef{level: LevelDistrict, id: subDistrict.DistrictID}]
+		if subDistrict.DeletedAt != nil {
+			deletedAt, parentDeleted = *subDistrict.DeletedAt, true
+		}
+		if parentDeleted {
+			live.deleted[nameRef{level: LevelSubDistrict, id: subDistrict.ID}] = deletedAt
+			continue
+		}
+		live.subDistricts = append(live.subDistricts, subDistrict)
+	}
+
+	if len(live.deleted) == 0 {
+		return d
+	}
+	// Share deletion times so include_deleted views still report them
+	d.deleted = live.deleted
+	return live
+}
+
+// GetDeletedAt returns when a record that is no longer served was deleted
+func (ds *DataService) GetDeletedAt(level string, id int) (time.Time, bool) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	deletedAt, deleted := ds.deleted[nameRef{level: level, id: id}]
+	return deletedAt, deleted
+}
+
+// IncludingDeleted returns a view of the current data that also serves
+// deleted records
+func (ds *DataService) IncludingDeleted() *DataService {
+	return &DataService{
+		dataPath: ds.dataPath,
+		dataset:  ds.current().withDeletedRecords,
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DiffEntry identifies a record that was added or removed
+type DiffEntry struct {
+	ID       int    `json:"id"`
+	NameTH   string `json:"name_th"`
+	NameEN   string `json:"name_en"`
+	ParentID int    `json:"parent_id"`
+}
+
+// DiffChange is a record whose field changed between two datasets; NameTH
+// and NameEN are the names in the newer dataset
+type DiffChange struct {
+	ID     int         `json:"id"`
+	NameTH string      `json:"name_th"`
+	NameEN string      `json:"name_en"`
+	From   interface{} `json:"from"`
+	To     interface{} `json:"to"`
+}
+
+// DiffName is a Thai and English name pair
+type DiffName struct {
+	NameTH string `json:"name_th"`
+	NameEN string `json:"name_en"`
+}
+
+// LevelDiff lists the differences within one level, each list ordered by ID
+type LevelDiff struct {
+	Added      []DiffEntry  `json:"added"`
+	Removed    []DiffEntry  `json:"removed"`
+	Renamed    []DiffChange `json:"renamed"`
+	Reparented []DiffChange `json:"reparented"`
+}
+
+// SubDistrictDiff adds the zip code and coordinate changes that only apply to
+// sub-districts; every list is always present, even when empty
+type SubDistrictDiff struct {
+	LevelDiff
+	ZipCodeChanged     []DiffChange `json:"zip_code_changed"`
+	CoordinatesChanged []DiffChange `json:"coordinates_changed"`
+}
+
+// DatasetDiff lists the differences between two datasets, keyed by ID
+type DatasetDiff struct {
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Identical    bool            `json:"identical"`
+	Provinces    LevelDiff       `json:"provinces"`
+	Districts    LevelDiff       `json:"districts"`
+	SubDistricts SubDistrictDiff `json:"subdistricts"`
+}
+
+// diffRecord is the comparable form of a record at any level
+type diffRecord struct {
+	name     DiffName
+	parentID int
+	zipCode  int
+	coord    *Coordinate
+}
+
+// diffDatasets compares two datasets. Only source coordinates are compared;
+// estimated ones follow their siblings and are treated as missing.
+func diffDatasets(fromName string, from *dataset, toName string, to *dataset) DatasetDiff {
+	diff := DatasetDiff{
+		From:         fromName,
+		To:           toName,
+		Provinces:    diffLevel(provinceRecords(from), provinceRecords(to)),
+		Districts:    diffLevel(districtRecords(from), districtRecords(to)),
+		SubDistricts: diffSubDistricts(subDistrictRecords(from), subDistrictRecords(to)),
+	}
+	diff.Identical = diff.Provinces.empty() && diff.Districts.empty() && diff.SubDistricts.empty()
+	return diff
+}
+
+// empty reports whether a level has no differences
+func (l LevelDiff) empty() bool {
+	return len(l.Added)+len(l.Removed)+len(l.Renamed)+len(l.Reparented) == 0
+}
+
+// empty reports whether the sub-districts have no differences
+func (l SubDistrictDiff) empty() bool {
+	return l.LevelDiff.empty() && len(l.ZipCodeChanged)+len(l.CoordinatesChanged) == 0
+}
+
+func provinceRecords(d *dataset) map[int]diffRecord {
+	records := make(map[int]diffRecord, len(d.provinces))
+	for _, province := range d.provinces {
+		records[province.ID] = diffRecord{
+			name:     DiffName{NameTH: province.NameTH, NameEN: province.NameEN},
+			parentID: province.GeographyID,
+		}
+	}
+	return records
+}
+
+func districtRecords(d *dataset) map[int]diffRecord {
+	records := make(map[int]diffRecord, len(d.districts))
+	for _, district := range d.districts {
+		records[district.ID] = diffRecord{
+			name:     DiffName{NameTH: district.NameTH, NameEN: district.NameEN},
+			parentID: district.ProvinceID,
+		}
+	}
+	return records
+}
+
+func subDistrictRecords(d *dataset) map[int]diffRecord {
+	records := make(map[int]diffRecord, len(d.subDistricts))
+	for _, subDistrict := range d.subDistricts {
+		record := diffRecord{
+			name:     DiffName{NameTH: subDistrict.NameTH, NameEN: subDistrict.NameEN},
+			parentID: subDistrict.DistrictID,
+			zipCode:  subDistrict.ZipCode,
+		}
+		if subDistrict.Lat != nil && subDistrict.Long != nil && subDistrict.CoordSource != CoordSourceEstimated {
+			record.coord = &Coordinate{Lat: *subDistrict.Lat, Long: *subDistrict.Long}
+		}
+		records[subDistrict.ID] = record
+	}
+	return records
+}
+
+// diffLevel compares the records of one level
+func diffLevel(from, to map[int]diffRecord) LevelDiff {
+	diff := LevelDiff{
+		Added:      make([]DiffEntry, 0),
+		Removed:    make([]DiffEntry, 0),
+		Renamed:    make([]DiffChange, 0),
+		Reparented: make([]DiffChange, 0),
+	}
+
+	for _, id := range sortedKeys(from) {
+		if _, exists := to[id]; !exists {
+			record := from[id]
+			diff.Removed = append(diff.Removed, DiffEntry{ID: id, NameTH: record.name.NameTH, NameEN: record.name.NameEN, ParentID: record.parentID})
+		}
+	}
+
+	for _, id := range sortedKeys(to) {
+		after := to[id]
+		before, exists := from[id]
+		if !exists {
+			diff.Added = append(diff.Added, DiffEntry{ID: id, NameTH: after.name.NameTH, NameEN: after.name.NameEN, ParentID: after.parentID})
+			continue
+		}
+
+		if before.name != after.name {
+			diff.Renamed = append(diff.Renamed, diffChange(id, after, before.name, after.name))
+		}
+		if before.parentID != after.parentID {
+			diff.Reparented = append(diff.Reparented, diffChange(id, after, before.parentID, after.parentID))
+		}
+	}
+
+	return diff
+}
+
+// diffSubDistricts compares sub-districts, including zip codes and coordinates
+func diffSubDistricts(from, to map[int]diffRecord) SubDistrictDiff {
+	diff := SubDistrictDiff{
+		LevelDiff:          diffLevel(from, to),
+		ZipCodeChanged:     make([]DiffChange, 0),
+		CoordinatesChanged: make([]DiffChange, 0),
+	}
+
+	for _, id := range sortedKeys(to) {
+		after := to[id]
+		before, exists := from[id]
+		if !exists {
+			continue
+		}
+		if before.zipCode != after.zipCode {
+			diff.ZipCodeChanged = append(diff.ZipCodeChanged, diffChange(id, after, before.zipCode, after.zipCode))
+		}
+		if !sameCoordinate(before.coord, after.coord) {
+			diff.CoordinatesChanged = append(diff.CoordinatesChanged, diffChange(id, after, before.coord, after.coord))
+		}
+	}
+
+	return diff
+}
+
+// diffChange describes a changed value of a record, named as it is now
+func diffChange(id int, record diffRecord, from, to interface{}) DiffChange {
+	return DiffChange{ID: id, NameTH: record.name.NameTH, NameEN: record.name.NameEN, From: from, To: to}
+}
+
+// sameCoordinate reports whether two optional coordinates are equal
+func sameCoordinate(a, b *Coordinate) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sortedKeys returns the IDs of a record map in ascending order
+func sortedKeys(records map[int]diffRecord) []int {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// runDiffCommand implements "diff <from> <to>": it compares two datasets,
+// given as directories or as version names under DATA_DIR, and prints the
+// differences as JSON. Like diff(1) it exits 0 when the datasets are
+// identical, 1 when they differ and 2 on error.
+func runDiffCommand(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: thai-location-api diff <from> <to>")
+		return 2
+	}
+
+	datasets := make([]*dataset, len(args))
+	for i, arg := range args {
+		dir := arg
+		if !hasDataFiles(dir) {
+			dir = filepath.Join(getEnv("DATA_DIR", "./data"), arg)
+		}
+		data, err := loadDataset(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load %s: %v\n", arg, err)
+			return 2
+		}
+		datasets[i] = data
+	}
+
+	diff := diffDatasets(args[0], datasets[0], args[1], datasets[1])
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(diff); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if diff.Identical {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Location reference types beyond the administrative levels
+const (
+	LocationGeography = "geography"
+	LocationLatLong   = "latlong"
+)
+
+var (
+	// errLocationNotFound is returned for references to unknown entities
+	errLocationNotFound = errors.New("Location not found")
+
+	// errNoCoordinates is returned for entities without known coordinates
+	errNoCoordinates = errors.New("Location has no coordinates")
+)
+
+// ResolveLocation resolves a reference of the form "type:id" (subdistrict,
+// district, province or geography) or "lat,long" to a point. Sub-districts use
+// their own coordinates; higher levels use their computed centroid.
+func (ds *DataService) ResolveLocation(ref string) (LocationPoint, error) {
+	point := LocationPoint{Ref: ref}
+
+	kind, value, found := strings.Cut(strings.TrimSpace(ref), ":")
+	if !found {
+		kind, value = LocationLatLong, kind
+	}
+	kind = strings.ToLower(kind)
+
+	if kind == LocationLatLong {
+		latStr, longStr, found := strings.Cut(value, ",")
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+		long, longErr := strconv.ParseFloat(strings.TrimSpace(longStr), 64)
+		if !found || latErr != nil || longErr != nil || !validCoordinates(lat, long) {
+			return point, fmt.Errorf("Invalid location %q: expected lat,long", ref)
+		}
+		point.Type = LocationLatLong
+		point.Coordinate = Coordinate{Lat: lat, Long: long}
+		return point, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return point, fmt.Errorf("Invalid location %q: expected type:id or lat,long", ref)
+	}
+	point.Type, point.ID = kind, id
+
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	var extent *Extent
+	switch kind {
+	case LevelSubDistrict:
+		subDistrict, exists := ds.subDistrictMap[id]
+		if !exists {
+			return point, fmt.Errorf("%w: %s", errLocationNotFound, ref)
+		}
+		point.NameTH, point.NameEN = subDistrict.NameTH, subDistrict.NameEN
+		if subDistrict.Lat == nil || subDistrict.Long == nil {
+			return point, fmt.Errorf("%w: %s", errNoCoordinates, ref)
+		}
+		point.Coordinate = Coordinate{Lat: *subDistrict.Lat, Long: *subDistrict.Long}
+		return point, nil
+	case LevelDistrict:
+		district, exists := ds.districtMap[id]
+		if !exists {
+			return point, fmt.Errorf("%w: %s", errLocationNotFound, ref)
+		}
+		point.NameTH, point.NameEN = district.NameTH, district.NameEN
+		extent = ds.districtExtents[id]
+	case LevelProvince:
+		province, exists := ds.provinceMap[id]
+		if !exists {
+			return point, fmt.Errorf("%w: %s", errLocationNotFound, ref)
+		}
+		point.NameTH, point.NameEN = province.NameTH, province.NameEN
+		extent = ds.provinceExtents[id]
+	case LocationGeography:
+		geography, exists := ds.geographyMap[id]
+		if !exists {
+			return point, fmt.Errorf("%w: %s", errLocationNotFound, ref)
+		}
+		point.NameTH = geography.Name
+		extent = ds.geographyExtents[id]
+	default:
+		return point, fmt.Errorf("Invalid location type %q: expected subdistrict, district, province, geography or latlong", kind)
+	}
+
+	if extent == nil {
+		return point, fmt.Errorf("%w: %s", errNoCoordinates, ref)
+	}
+	point.Coordinate = extent.Centroid
+	return point, nil
+}
+
+// pointDistance returns the great-circle distance between two resolved points
+func pointDistance(from, to LocationPoint) float64 {
+	return haversineMeters(from.Lat, from.Long, to.Lat, to.Long)
+}
+
+// locationErrorStatus maps a ResolveLocation error to an HTTP status code
+func locationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errLocationNotFound):
+		return 404
+	case errors.Is(err, errNoCoordinates):
+		return 422
+	}
+	return 400
+}
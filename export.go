@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// exportFormatVersion identifies the layout of the compact export
+const exportFormatVersion = 1
+
+// exportBrotliLevel trades a little size for load time; the best level (11)
+// takes seconds per payload and would slow every startup and reload
+const exportBrotliLevel = 9
+
+// exportPayload is a pre-serialized export response, compressed once per
+// supported encoding, and its content hash
+type exportPayload struct {
+	Body       []byte
+	GzipBody   []byte
+	BrotliBody []byte
+	Version    string
+}
+
+// encoded returns the body for the best encoding the client accepts and the
+// Content-Encoding to send with it ("" for identity)
+func (p exportPayload) encoded(c *fiber.Ctx) ([]byte, string) {
+	switch {
+	case c.Context().Request.Header.HasAcceptEncoding("br"):
+		return p.BrotliBody, "br"
+	case c.Context().Request.Header.HasAcceptEncoding("gzip"):
+		return p.GzipBody, "gzip"
+	}
+	return p.Body, ""
+}
+
+// CompactExport is the normalized full dataset, each level an array of
+// tuples whose order is described by Columns
+type CompactExport struct {
+	Format       int                 `json:"format"`
+	Version      string              `json:"version"`
+	Columns      map[string][]string `json:"columns"`
+	Geographies  [][]interface{}     `json:"geographies"`
+	Provinces    [][]interface{}     `json:"provinces"`
+	Districts    [][]interface{}     `json:"districts"`
+	SubDistricts [][]interface{}     `json:"subdistricts"`
+}
+
+// ExportVersion describes the current export without the data
+type ExportVersion struct {
+	Format  int            `json:"format"`
+	Version string         `json:"version"`
+	Counts  map[string]int `json:"counts"`
+}
+
+// buildExport serializes the dataset into the compact export format. The
+// version is a hash of the tuples, so it changes only when the data does.
+func buildExport(geographies []Geography, provinces []Province, districts []District, subDistricts []SubDistrict, withTimestamps bool) exportPayload {
+	export := CompactExport{
+		Format: exportFormatVersion,
+		Columns: map[string][]string{
+			"geographies":  {"id", "name"},
+			"provinces":    {"id", "name_th", "name_en", "geography_id"},
+			"districts":    {"id", "name_th", "name_en", "province_id"},
+			"subdistricts": {"id", "zip_code", "name_th", "name_en", "district_id", "lat", "long"},
+		},
+		Geographies:  make([][]interface{}, 0, len(geographies)),
+		Provinces:    make([][]interface{}, 0, len(provinces)),
+		Districts:    make([][]interface{}, 0, len(districts)),
+		SubDistricts: make([][]interface{}, 0, len(subDistricts)),
+	}
+
+	if withTimestamps {
+		for _, level := range []string{"provinces", "districts", "subdistricts"} {
+			export.Columns[level] = append(export.Columns[level], "created_at", "updated_at", "deleted_at")
+		}
+	}
+	timestamps := func(createdAt, updatedAt time.Time, deletedAt *time.Time) []interface{} {
+		if !withTimestamps {
+			return nil
+		}
+		return []interface{}{createdAt, updatedAt, deletedAt}
+	}
+
+	for _, geography := range geographies {
+		export.Geographies = append(export.Geographies, []interface{}{geography.ID, geography.Name})
+	}
+	for _, province := range provinces {
+		export.Provinces = append(export.Provinces, append([]interface{}{
+			province.ID, province.NameTH, province.NameEN, province.GeographyID,
+		}, timestamps(province.CreatedAt, province.UpdatedAt, province.DeletedAt)...))
+	}
+	for _, district := range districts {
+		export.Districts = append(export.Districts, append([]interface{}{
+			district.ID, district.NameTH, district.NameEN, district.ProvinceID,
+		}, timestamps(district.CreatedAt, district.UpdatedAt, district.DeletedAt)...))
+	}
+	for _, subDistrict := range subDistricts {
+		// Export source coordinates only, not estimates
+		lat, long := subDistrict.Lat, subDistrict.Long
+		if subDistrict.CoordSource == CoordSourceEstimated {
+			lat, long = nil, nil
+		}
+		export.SubDistricts = append(export.SubDistricts, append([]interface{}{
+			subDistrict.ID, subDistrict.ZipCode, subDistrict.NameTH, subDistrict.NameEN,
+			subDistrict.DistrictID, lat, long,
+		}, timestamps(subDistrict.CreatedAt, subDistrict.UpdatedAt, subDistrict.DeletedAt)...))
+	}
+
+	// Hash the data before the version is filled in
+	content, _ := json.Marshal(export)
+	sum := sha256.Sum256(content)
+	export.Version = hex.EncodeToString(sum[:])[:16]
+
+	body, _ := json.Marshal(APIResponse{
+		Status: "success",
+		Data:   export,
+	})
+
+	return exportPayload{
+		Body:       body,
+		GzipBody:   fasthttp.AppendGzipBytesLevel(nil, body, fasthttp.CompressBestCompression),
+		BrotliBody: fasthttp.AppendBrotliBytesLevel(nil, body, exportBrotliLevel),
+		Version:    export.Version,
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"unicode"
+)
+
+// fuzzyThreshold is the minimum similarity for a fuzzy search hit
+const fuzzyThreshold = 0.5
+
+// fuzzyKey drops spaces and punctuation from normalized text so that
+// "Chiangmai", "Chiang Mai" and "chiang-mai" compare equal
+func fuzzyKey(normalized string) []rune {
+	key := make([]rune, 0, len(normalized))
+	for _, r := range normalized {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			key = append(key, r)
+		}
+	}
+	return key
+}
+
+// fuzzyScore returns a similarity between 0 and 1 for a query and the name of
+// an entity at the given level, taking the better of edit-distance and
+// trigram similarity
+func fuzzyScore(query, name, level string, ignoreTones bool) float64 {
+	q := fuzzyKey(normalizeSearchText(query, ignoreTones))
+	n := fuzzyKey(normalizeName(name, level, ignoreTones))
+	if len(q) == 0 || len(n) == 0 {
+		return 0
+	}
+
+	return math.Max(levenshteinSimilarity(q, n), trigramSimilarity(q, n))
+}
+
+// levenshteinSimilarity is 1 minus the edit distance normalized by the longer length
+func levenshteinSimilarity(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// trigramSimilarity is the Jaccard similarity of the padded trigram sets
+func trigramSimilarity(a, b []rune) float64 {
+	ta := trigrams(a)
+	tb := trigrams(b)
+
+	shared := 0
+	for gram := range ta {
+		if tb[gram] {
+			shared++
+		}
+	}
+
+	union := len(ta) + len(tb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// trigrams returns the set of trigrams of a key padded with spaces
+func trigrams(key []rune) map[string]bool {
+	padded := append([]rune("  "), key...)
+	padded = append(padded, ' ')
+
+	grams := make(map[string]bool, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		grams[string(padded[i:i+3])] = true
+	}
+	return grams
+}
+
+// bestFuzzyScore scores a query against both names of an entity
+func bestFuzzyScore(query, level, nameEN, nameTH string, ignoreTones bool) float64 {
+	return math.Max(fuzzyScore(query, nameEN, level, ignoreTones), fuzzyScore(query, nameTH, level, ignoreTones))
+}
+
+// roundScore rounds a score to three decimals for output
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+// fuzzyRank returns the items similar to the query, best first, and their
+// scores by ID
+func fuzzyRank[T keyed](items []T, query, level string, ignoreTones bool, names func(T) (string, string)) ([]T, map[int]float64) {
+	ranked := make([]T, 0)
+	scores := make(map[int]float64)
+	for _, item := range items {
+		nameEN, nameTH := names(item)
+		if score := bestFuzzyScore(query, level, nameEN, nameTH, ignoreTones); score >= fuzzyThreshold {
+			ranked = append(ranked, item)
+			scores[item.key()] = roundScore(score)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].key()] > scores[ranked[j].key()]
+	})
+	return ranked, scores
+}
+
+// fuzzyProvinces returns provinces similar to the query, best first, and their scores
+func fuzzyProvinces(provinces []Province, query string, ignoreTones bool) ([]Province, map[int]float64) {
+	return fuzzyRank(provinces, query, LevelProvince, ignoreTones, func(p Province) (string, string) {
+		return p.NameEN, p.NameTH
+	})
+}
+
+// fuzzyDistricts returns districts similar to the query, best first, and their scores
+func fuzzyDistricts(districts []District, query string, ignoreTones bool) ([]District, map[int]float64) {
+	return fuzzyRank(districts, query, LevelDistrict, ignoreTones, func(d District) (string, string) {
+		return d.NameEN, d.NameTH
+	})
+}
+
+// fuzzySubDistricts returns sub-districts similar to the query, best first, and their scores
+func fuzzySubDistricts(subDistricts []SubDistrict, query string, ignoreTones bool) ([]SubDistrict, map[int]float64) {
+	return fuzzyRank(subDistricts, query, LevelSubDistrict, ignoreTones, func(s SubDistrict) (string, string) {
+		return s.NameEN, s.NameTH
+	})
+}
+
+// scoredProvinces attaches fuzzy scores to a page of provinces
+func scoredProvinces(provinces []ProvinceWithGeography, scores map[int]float64) []ScoredProvince {
+	results := make([]ScoredProvince, 0, len(provinces))
+	for _, province := range provinces {
+		results = append(results, ScoredProvince{ProvinceWithGeography: province, Score: scores[province.ID]})
+	}
+	return results
+}
+
+// scoredDistricts attaches fuzzy scores to a page of districts
+func scoredDistricts(districts []DistrictWithProvince, scores map[int]float64) []ScoredDistrict {
+	results := make([]ScoredDistrict, 0, len(districts))
+	for _, district := range districts {
+		results = append(results, ScoredDistrict{DistrictWithProvince: district, Score: scores[district.ID]})
+	}
+	return results
+}
+
+// scoredSubDistricts attaches fuzzy scores to a page of sub-districts
+func scoredSubDistricts(subDistricts []SubDistrictWithHierarchy, scores map[int]float64) []ScoredSubDistrict {
+	results := make([]ScoredSubDistrict, 0, len(subDistricts))
+	for _, subDistrict := range subDistricts {
+		results = append(results, ScoredSubDistrict{SubDistrictWithHierarchy: subDistrict, Score: scores[subDistrict.ID]})
+	}
+	return results
+}
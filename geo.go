@@ -0,0 +1,343 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// earthRadiusMeters is the mean Earth radius used for great-circle distances
+	earthRadiusMeters = 6371008.8
+
+	// metersPerDegreeLat is the approximate length of one degree of latitude
+	metersPerDegreeLat = 111320.0
+
+	// gridCellSize is the size of a spatial grid cell in degrees (~11 km)
+	gridCellSize = 0.1
+)
+
+// haversineMeters returns the great-circle distance between two points in meters
+func haversineMeters(lat1, long1, lat2, long2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (long2 - long1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// validCoordinates reports whether lat/long fall within WGS84 bounds
+func validCoordinates(lat, long float64) bool {
+	return lat >= -90 && lat <= 90 && long >= -180 && long <= 180
+}
+
+// gridCell identifies a cell of the spatial grid
+type gridCell struct {
+	lat  int
+	long int
+}
+
+// spatialGrid is a fixed-size lat/long grid over sub-districts with coordinates
+type spatialGrid struct {
+	cells map[gridCell][]SubDistrict
+
+	// Bounds of the occupied cells, used to stop ring searches
+	minCell gridCell
+	maxCell gridCell
+
+	// maxAbsLat is the largest absolute latitude indexed, used to bound the
+	// east-west length of a degree when pruning searches
+	maxAbsLat float64
+}
+
+// newSpatialGrid builds a grid from sub-districts, skipping those without coordinates
+func newSpatialGrid(subDistricts []SubDistrict) *spatialGrid {
+	grid := &spatialGrid{
+		cells: make(map[gridCell][]SubDistrict),
+	}
+
+	first := true
+	for _, subDistrict := range subDistricts {
+		if subDistrict.Lat == nil || subDistrict.Long == nil {
+			continue
+		}
+
+		cell := cellFor(*subDistrict.Lat, *subDistrict.Long)
+		grid.cells[cell] = append(grid.cells[cell], subDistrict)
+
+		if first {
+			grid.minCell, grid.maxCell = cell, cell
+			first = false
+		}
+		grid.minCell.lat = min(grid.minCell.lat, cell.lat)
+		grid.minCell.long = min(grid.minCell.long, cell.long)
+		grid.maxCell.lat = max(grid.maxCell.lat, cell.lat)
+		grid.maxCell.long = max(grid.maxCell.long, cell.long)
+		grid.maxAbsLat = math.Max(grid.maxAbsLat, math.Abs(*subDistrict.Lat))
+	}
+
+	return grid
+}
+
+// cellFor returns the grid cell containing a coordinate
+func cellFor(lat, long float64) gridCell {
+	return gridCell{
+		lat:  int(math.Floor(lat / gridCellSize)),
+		long: int(math.Floor(long / gridCellSize)),
+	}
+}
+
+// empty reports whether the grid holds no points
+func (g *spatialGrid) empty() bool {
+	return len(g.cells) == 0
+}
+
+// nearest returns the sub-district closest to the given point and its distance in meters
+func (g *spatialGrid) nearest(lat, long float64) (SubDistrict, float64, bool) {
+	var best SubDistrict
+	bestDistance := math.Inf(1)
+	found := false
+
+	if g.empty() {
+		return best, 0, false
+	}
+
+	origin := cellFor(lat, long)
+	minMetersPerDegree := g.minMetersPerDegreeLong(lat)
+
+	// Outside the occupied cells a ring search would mostly visit empty
+	// cells, so compare against every point instead
+	if origin.lat < g.minCell.lat || origin.lat > g.maxCell.lat ||
+		origin.long < g.minCell.long || origin.long > g.maxCell.long {
+		for _, subDistricts := range g.cells {
+			for _, subDistrict := range subDistricts {
+				distance := haversineMeters(lat, long, *subDistrict.Lat, *subDistrict.Long)
+				if distance < bestDistance {
+					best = subDistrict
+					bestDistance = distance
+					found = true
+				}
+			}
+		}
+		return best, bestDistance, found
+	}
+
+	// Maximum ring needed to cover every occupied cell from the origin
+	maxRing := max(
+		abs(origin.lat-g.minCell.lat), abs(origin.lat-g.maxCell.lat),
+		abs(origin.long-g.minCell.long), abs(origin.long-g.maxCell.long),
+	)
+
+	for ring := 0; ring <= maxRing; ring++ {
+		// Every point in this ring is at least (ring-1) cells away on one axis
+		if found && ring > 0 {
+			lowerBound := float64(ring-1) * gridCellSize * minMetersPerDegree
+			if lowerBound > bestDistance {
+				break
+			}
+		}
+
+		for _, cell := range ringCells(origin, ring) {
+			for _, subDistrict := range g.cells[cell] {
+				distance := haversineMeters(lat, long, *subDistrict.Lat, *subDistrict.Long)
+				if distance < bestDistance {
+					best = subDistrict
+					bestDistance = distance
+					found = true
+				}
+			}
+		}
+	}
+
+	return best, bestDistance, found
+}
+
+// withinRadius returns sub-districts within radius meters of a point, nearest first
+func (g *spatialGrid) withinRadius(lat, long, radius float64) []SubDistrictWithDistance {
+	results := make([]SubDistrictWithDistance, 0)
+	if g.empty() {
+		return results
+	}
+
+	// Cell range covering the radius on each axis
+	dLat := radius / metersPerDegreeLat
+	dLong := radius / g.minMetersPerDegreeLong(lat)
+	from := cellFor(lat-dLat, long-dLong)
+	to := cellFor(lat+dLat, long+dLong)
+
+	g.eachCell(from, to, func(subDistrict SubDistrict) {
+		distance := haversineMeters(lat, long, *subDistrict.Lat, *subDistrict.Long)
+		if distance <= radius {
+			results = append(results, SubDistrictWithDistance{
+				SubDistrict:    subDistrict,
+				DistanceMeters: distance,
+			})
+		}
+	})
+
+	sortByDistance(results)
+	return results
+}
+
+// withinBounds returns sub-districts inside a bounding box, ordered by
+// distance from the box center
+func (g *spatialGrid) withinBounds(minLong, minLat, maxLong, maxLat float64) []SubDistrictWithDistance {
+	results := make([]SubDistrictWithDistance, 0)
+	if g.empty() {
+		return results
+	}
+
+	centerLat := (minLat + maxLat) / 2
+	centerLong := (minLong + maxLong) / 2
+
+	g.eachCell(cellFor(minLat, minLong), cellFor(maxLat, maxLong), func(subDistrict SubDistrict) {
+		lat, long := *subDistrict.Lat, *subDistrict.Long
+		if lat < minLat || lat > maxLat || long < minLong || long > maxLong {
+			return
+		}
+		results = append(results, SubDistrictWithDistance{
+			SubDistrict:    subDistrict,
+			DistanceMeters: haversineMeters(centerLat, centerLong, lat, long),
+		})
+	})
+
+	sortByDistance(results)
+	return results
+}
+
+// eachCell calls fn for every sub-district in the cell range, clipped to the occupied bounds
+func (g *spatialGrid) eachCell(from, to gridCell, fn func(SubDistrict)) {
+	for latIdx := max(from.lat, g.minCell.lat); latIdx <= min(to.lat, g.maxCell.lat); latIdx++ {
+		for longIdx := max(from.long, g.minCell.long); longIdx <= min(to.long, g.maxCell.long); longIdx++ {
+			for _, subDistrict := range g.cells[gridCell{lat: latIdx, long: longIdx}] {
+				fn(subDistrict)
+			}
+		}
+	}
+}
+
+// sortByDistance orders results nearest first, breaking ties by ID
+func sortByDistance(results []SubDistrictWithDistance) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceMeters != results[j].DistanceMeters {
+			return results[i].DistanceMeters < results[j].DistanceMeters
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// minMetersPerDegreeLong returns the smallest east-west length of a degree
+// between the query latitude and the indexed points
+func (g *spatialGrid) minMetersPerDegreeLong(lat float64) float64 {
+	maxLat := math.Min(math.Max(g.maxAbsLat, math.Abs(lat))+gridCellSize, 89.9)
+	return metersPerDegreeLat * math.Cos(maxLat*math.Pi/180)
+}
+
+// ringCells returns the cells at exactly the given Chebyshev distance from origin
+func ringCells(origin gridCell, ring int) []gridCell {
+	if ring == 0 {
+		return []gridCell{origin}
+	}
+
+	cells := make([]gridCell, 0, 8*ring)
+	for dLong := -ring; dLong <= ring; dLong++ {
+		cells = append(cells,
+			gridCell{lat: origin.lat - ring, long: origin.long + dLong},
+			gridCell{lat: origin.lat + ring, long: origin.long + dLong})
+	}
+	for dLat := -ring + 1; dLat <= ring-1; dLat++ {
+		cells = append(cells,
+			gridCell{lat: origin.lat + dLat, long: origin.long - ring},
+			gridCell{lat: origin.lat + dLat, long: origin.long + ring})
+	}
+	return cells
+}
+
+// abs returns the absolute value of an int
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// extentBuilder accumulates points into a centroid and bounding box
+type extentBuilder struct {
+	count   int
+	sumLat  float64
+	sumLong float64
+	bbox    BoundingBox
+}
+
+// add includes a point in the extent
+func (b *extentBuilder) add(lat, long float64) {
+	if b.count == 0 {
+		b.bbox = BoundingBox{MinLat: lat, MinLong: long, MaxLat: lat, MaxLong: long}
+	} else {
+		b.bbox.MinLat = math.Min(b.bbox.MinLat, lat)
+		b.bbox.MinLong = math.Min(b.bbox.MinLong, long)
+		b.bbox.MaxLat = math.Max(b.bbox.MaxLat, lat)
+		b.bbox.MaxLong = math.Max(b.bbox.MaxLong, long)
+	}
+	b.count++
+	b.sumLat += lat
+	b.sumLong += long
+}
+
+// extent returns the accumulated extent, or nil if no points were added.
+// Thailand spans a small area so the arithmetic mean is a fair centroid.
+func (b *extentBuilder) extent() *Extent {
+	if b.count == 0 {
+		return nil
+	}
+	return &Extent{
+		Centroid: Coordinate{
+			Lat:  b.sumLat / float64(b.count),
+			Long: b.sumLong / float64(b.count),
+		},
+		BBox: b.bbox,
+	}
+}
+
+// buildExtents computes the extent of every district, province and geography
+// from the source coordinates of the sub-districts they contain; estimates
+// would give districts with many missing points extra weight
+func buildExtents(subDistricts []SubDistrict, districtMap map[int]District, provinceMap map[int]Province) (districts, provinces, geographies map[int]*Extent) {
+	districtBuilders := make(map[int]*extentBuilder)
+	provinceBuilders := make(map[int]*extentBuilder)
+	geographyBuilders := make(map[int]*extentBuilder)
+	builder := func(builders map[int]*extentBuilder, id int) *extentBuilder {
+		if builders[id] == nil {
+			builders[id] = &extentBuilder{}
+		}
+		return builders[id]
+	}
+
+	for _, subDistrict := range subDistricts {
+		if subDistrict.Lat == nil || subDistrict.Long == nil || subDistrict.CoordSource == CoordSourceEstimated {
+			continue
+		}
+		lat, long := *subDistrict.Lat, *subDistrict.Long
+
+		builder(districtBuilders, subDistrict.DistrictID).add(lat, long)
+		district, exists := districtMap[subDistrict.DistrictID]
+		if !exists {
+			continue
+		}
+		builder(provinceBuilders, district.ProvinceID).add(lat, long)
+		if province, exists := provinceMap[district.ProvinceID]; exists {
+			builder(geographyBuilders, province.GeographyID).add(lat, long)
+		}
+	}
+
+	finish := func(builders map[int]*extentBuilder) map[int]*Extent {
+		extents := make(map[int]*Extent, len(builders))
+		for id, b := range builders {
+			extents[id] = b.extent()
+		}
+		return extents
+	}
+	return finish(districtBuilders), finish(provinceBuilders), finish(geographyBuilders)
+}
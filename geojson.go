@@ -0,0 +1,63 @@
+package main
+
+// FeatureCollection is a GeoJSON feature collection. SkippedIDs lists records
+// left out because they have no coordinates.
+type FeatureCollection struct {
+	Type       string    `json:"type"`
+	Features   []Feature `json:"features"`
+	SkippedIDs []int     `json:"skipped_ids,omitempty"`
+}
+
+// Feature is a GeoJSON feature with a point geometry
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         int                    `json:"id"`
+	Geometry   PointGeometry          `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// PointGeometry is a GeoJSON point; coordinates are [long, lat]
+type PointGeometry struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// subDistrictFeatures converts sub-districts into Point features, skipping
+// those without coordinates
+func (h *LocationHandler) subDistrictFeatures(subDistricts []SubDistrict) FeatureCollection {
+	collection := FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]Feature, 0, len(subDistricts)),
+	}
+
+	for _, subDistrict := range subDistricts {
+		if subDistrict.Lat == nil || subDistrict.Long == nil {
+			collection.SkippedIDs = append(collection.SkippedIDs, subDistrict.ID)
+			continue
+		}
+
+		district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
+		feature := Feature{
+			Type: "Feature",
+			ID:   subDistrict.ID,
+			Geometry: PointGeometry{
+				Type:        "Point",
+				Coordinates: [2]float64{*subDistrict.Long, *subDistrict.Lat},
+			},
+			Properties: map[string]interface{}{
+				"id":          subDistrict.ID,
+				"name_th":     subDistrict.NameTH,
+				"name_en":     subDistrict.NameEN,
+				"zip_code":    subDistrict.ZipCode,
+				"district_id": subDistrict.DistrictID,
+				"province_id": district.ProvinceID,
+			},
+		}
+		if subDistrict.CoordSource != "" {
+			feature.Properties["coord_source"] = subDistrict.CoordSource
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	return collection
+}
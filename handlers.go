@@ -1,21 +1,44 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// maxNearbyRadiusMeters caps the radius accepted by the nearby search
+const maxNearbyRadiusMeters = 200000
+
+// datasetVersionHeader selects a dataset version and names the one served
+const datasetVersionHeader = "X-Dataset-Version"
+
+// maxAddressLength caps the size of a free-form address in bytes
+const maxAddressLength = 1000
+
+// HandlerConfig holds tunable limits for LocationHandler
+type HandlerConfig struct {
+	// MaxBatchSize caps the total number of items in a batch request
+	MaxBatchSize int
+	
+	// MaxMatrixSize caps the origins and the destinations of a distance matrix
+	MaxMatrixSize int
+}
+
 // LocationHandler handles HTTP requests for location data
 type LocationHandler struct {
 	dataService *DataService
+	config      HandlerConfig
 }
 
 // NewLocationHandler creates a new LocationHandler
-func NewLocationHandler(dataService *DataService) *LocationHandler {
+func NewLocationHandler(dataService *DataService, config HandlerConfig) *LocationHandler {
 	return &LocationHandler{
 		dataService: dataService,
+		config:      config,
 	}
 }
 
@@ -23,9 +46,54 @@ func NewLocationHandler(dataService *DataService) *LocationHandler {
 func (h *LocationHandler) GetGeographies(c *fiber.Ctx) error {
 	geographies := h.dataService.GetGeographies()
 	
+	// Embed related information if requested
+	includes, err := parseIncludes(c.Query("include"), geographyRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   h.expandGeographies(geographies, includes),
+	})
+}
+
+// GetGeographyByID returns a specific geography with its computed extent
+func (h *LocationHandler) GetGeographyByID(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid geography ID",
+		})
+	}
+	
+	geography, exists := h.dataService.GetGeography(id)
+	if !exists {
+		return c.Status(404).JSON(APIResponse{
+			Status: "error",
+			Error:  "Geography not found",
+		})
+	}
+	
+	// Include related information if requested
+	includes, err := parseIncludes(c.Query("include"), geographyRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	result := h.expandGeography(geography, includes)
+	result.Extent = h.dataService.GetGeographyExtent(geography.ID)
+	
 	return c.JSON(APIResponse{
 		Status: "success",
-		Data:   geographies,
+		Data:   result,
 	})
 }
 
@@ -33,6 +101,7 @@ func (h *LocationHandler) GetGeographies(c *fiber.Ctx) error {
 func (h *LocationHandler) GetProvinces(c *fiber.Ctx) error {
 	geographyIDStr := c.Query("geography_id")
 	search := strings.ToLower(c.Query("search"))
+	matcher := newSearchMatcher(search, c.QueryBool("ignore_tones"))
 	
 	provinces := h.dataService.GetProvinces()
 	
@@ -48,21 +117,73 @@ func (h *LocationHandler) GetProvinces(c *fiber.Ctx) error {
 		provinces = h.dataService.GetProvincesByGeography(geographyID)
 	}
 	
-	// Filter by search term if provided
-	if search != "" {
+	// Filter by search term if provided, ranking by similarity instead of
+	// substring matching when fuzzy is requested
+	var scores map[int]float64
+	if search != "" && c.QueryBool("fuzzy") {
+		provinces, scores = fuzzyProvinces(provinces, search, matcher.ignoreTones)
+	} else if search != "" {
 		filteredProvinces := make([]Province, 0)
 		for _, province := range provinces {
-			if strings.Contains(strings.ToLower(province.NameTH), search) ||
-				strings.Contains(strings.ToLower(province.NameEN), search) {
+			if matcher.matches(LevelProvince, province.NameTH, province.NameEN) {
 				filteredProvinces = append(filteredProvinces, province)
 			}
 		}
 		provinces = filteredProvinces
 	}
 	
+	// Apply sort order if provided, keeping fuzzy scores as the last key
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorter := applySort[Province]
+		if scores != nil {
+			sorter = applyRankedSort[Province]
+		}
+		sorted, err := sorter(provinces, sortParam, provinceSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		provinces = sorted
+	}
+	
+	// Send the full filtered list as a spreadsheet if requested
+	format, err := outputFormat(c, FormatCSV, FormatXLSX)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if format != "" {
+		return sendTable(c, format, "provinces", h.provinceTable(provinces, c.QueryBool("parents")))
+	}
+	
 	// Add pagination
-	page, limit := getPaginationParams(c)
-	paginatedData, pagination := paginate(provinces, page, limit)
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(provinces, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested, and attach fuzzy scores
+	includes, err := parseIncludes(c.Query("include"), provinceRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	expanded := h.expandProvinces(paginatedData.([]Province), includes)
+	if scores != nil {
+		paginatedData = scoredProvinces(expanded, scores)
+	} else {
+		paginatedData = expanded
+	}
 	
 	return c.JSON(PaginatedResponse{
 		Status:     "success",
@@ -84,22 +205,85 @@ func (h *LocationHandler) GetProvinceByID(c *fiber.Ctx) error {
 	
 	province, exists := h.dataService.GetProvince(id)
 	if !exists {
+		return h.notFound(c, LevelProvince, id, "Province not found")
+	}
+	
+	// Include related information, geography by default
+	includes, err := parseIncludes(c.Query("include"), provinceRelations, defaultProvinceIncludes)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	result := h.expandProvince(province, includes)
+	result.Extent = h.dataService.GetProvinceExtent(province.ID)
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// GetProvinceBounds returns the centroid and bounding box of a province for map zooming
+func (h *LocationHandler) GetProvinceBounds(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid province ID",
+		})
+	}
+	
+	if _, exists := h.dataService.GetProvince(id); !exists {
+		return h.notFound(c, LevelProvince, id, "Province not found")
+	}
+	
+	extent := h.dataService.GetProvinceExtent(id)
+	if extent == nil {
 		return c.Status(404).JSON(APIResponse{
 			Status: "error",
-			Error:  "Province not found",
+			Error:  "No coordinates available for this province",
+		})
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   ProvinceBounds{ProvinceID: id, Extent: *extent},
+	})
+}
+
+// GetProvinceTree returns a province with its nested districts and sub-districts
+func (h *LocationHandler) GetProvinceTree(c *fiber.Ctx) error {
+	idStr := c.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid province ID",
 		})
 	}
 	
-	// Include geography information
-	geography, _ := h.dataService.GetGeography(province.GeographyID)
-	result := ProvinceWithGeography{
-		Province:  province,
-		Geography: &geography,
+	depth := 2
+	if depthStr := c.Query("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 0 || depth > 2 {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  "Invalid depth parameter",
+			})
+		}
+	}
+	
+	tree, exists := h.dataService.GetProvinceTree(id, depth)
+	if !exists {
+		return h.notFound(c, LevelProvince, id, "Province not found")
 	}
 	
 	return c.JSON(APIResponse{
 		Status: "success",
-		Data:   result,
+		Data:   tree,
 	})
 }
 
@@ -117,30 +301,80 @@ func (h *LocationHandler) GetDistrictsByProvinceID(c *fiber.Ctx) error {
 	// Check if province exists
 	_, exists := h.dataService.GetProvince(provinceID)
 	if !exists {
-		return c.Status(404).JSON(APIResponse{
-			Status: "error",
-			Error:  "Province not found",
-		})
+		return h.notFound(c, LevelProvince, provinceID, "Province not found")
 	}
 	
 	districts := h.dataService.GetDistrictsByProvince(provinceID)
 	search := strings.ToLower(c.Query("search"))
+	matcher := newSearchMatcher(search, c.QueryBool("ignore_tones"))
 	
-	// Filter by search term if provided
-	if search != "" {
+	// Filter by search term if provided, ranking by similarity instead of
+	// substring matching when fuzzy is requested
+	var scores map[int]float64
+	if search != "" && c.QueryBool("fuzzy") {
+		districts, scores = fuzzyDistricts(districts, search, matcher.ignoreTones)
+	} else if search != "" {
 		filteredDistricts := make([]District, 0)
 		for _, district := range districts {
-			if strings.Contains(strings.ToLower(district.NameTH), search) ||
-				strings.Contains(strings.ToLower(district.NameEN), search) {
+			if matcher.matches(LevelDistrict, district.NameTH, district.NameEN) {
 				filteredDistricts = append(filteredDistricts, district)
 			}
 		}
 		districts = filteredDistricts
 	}
 	
+	// Apply sort order if provided, keeping fuzzy scores as the last key
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorter := applySort[District]
+		if scores != nil {
+			sorter = applyRankedSort[District]
+		}
+		sorted, err := sorter(districts, sortParam, districtSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		districts = sorted
+	}
+	
+	// Send the full filtered list as a spreadsheet if requested
+	format, err := outputFormat(c, FormatCSV, FormatXLSX)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if format != "" {
+		return sendTable(c, format, "districts", h.districtTable(districts, c.QueryBool("parents")))
+	}
+	
 	// Add pagination
-	page, limit := getPaginationParams(c)
-	paginatedData, pagination := paginate(districts, page, limit)
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(districts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested, and attach fuzzy scores
+	includes, err := parseIncludes(c.Query("include"), districtRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	expanded := h.expandDistricts(paginatedData.([]District), includes)
+	if scores != nil {
+		paginatedData = scoredDistricts(expanded, scores)
+	} else {
+		paginatedData = expanded
+	}
 	
 	return c.JSON(PaginatedResponse{
 		Status:     "success",
@@ -153,6 +387,7 @@ func (h *LocationHandler) GetDistrictsByProvinceID(c *fiber.Ctx) error {
 func (h *LocationHandler) GetDistricts(c *fiber.Ctx) error {
 	provinceIDStr := c.Query("province_id")
 	search := strings.ToLower(c.Query("search"))
+	matcher := newSearchMatcher(search, c.QueryBool("ignore_tones"))
 	
 	districts := h.dataService.GetDistricts()
 	
@@ -168,21 +403,73 @@ func (h *LocationHandler) GetDistricts(c *fiber.Ctx) error {
 		districts = h.dataService.GetDistrictsByProvince(provinceID)
 	}
 	
-	// Filter by search term if provided
-	if search != "" {
+	// Filter by search term if provided, ranking by similarity instead of
+	// substring matching when fuzzy is requested
+	var scores map[int]float64
+	if search != "" && c.QueryBool("fuzzy") {
+		districts, scores = fuzzyDistricts(districts, search, matcher.ignoreTones)
+	} else if search != "" {
 		filteredDistricts := make([]District, 0)
 		for _, district := range districts {
-			if strings.Contains(strings.ToLower(district.NameTH), search) ||
-				strings.Contains(strings.ToLower(district.NameEN), search) {
+			if matcher.matches(LevelDistrict, district.NameTH, district.NameEN) {
 				filteredDistricts = append(filteredDistricts, district)
 			}
 		}
 		districts = filteredDistricts
 	}
 	
+	// Apply sort order if provided, keeping fuzzy scores as the last key
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorter := applySort[District]
+		if scores != nil {
+			sorter = applyRankedSort[District]
+		}
+		sorted, err := sorter(districts, sortParam, districtSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		districts = sorted
+	}
+	
+	// Send the full filtered list as a spreadsheet if requested
+	format, err := outputFormat(c, FormatCSV, FormatXLSX)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if format != "" {
+		return sendTable(c, format, "districts", h.districtTable(districts, c.QueryBool("parents")))
+	}
+	
 	// Add pagination
-	page, limit := getPaginationParams(c)
-	paginatedData, pagination := paginate(districts, page, limit)
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(districts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested, and attach fuzzy scores
+	includes, err := parseIncludes(c.Query("include"), districtRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	expanded := h.expandDistricts(paginatedData.([]District), includes)
+	if scores != nil {
+		paginatedData = scoredDistricts(expanded, scores)
+	} else {
+		paginatedData = expanded
+	}
 	
 	return c.JSON(PaginatedResponse{
 		Status:     "success",
@@ -204,18 +491,19 @@ func (h *LocationHandler) GetDistrictByID(c *fiber.Ctx) error {
 	
 	district, exists := h.dataService.GetDistrict(id)
 	if !exists {
-		return c.Status(404).JSON(APIResponse{
-			Status: "error",
-			Error:  "District not found",
-		})
+		return h.notFound(c, LevelDistrict, id, "District not found")
 	}
 	
-	// Include province information
-	province, _ := h.dataService.GetProvince(district.ProvinceID)
-	result := DistrictWithProvince{
-		District: district,
-		Province: &province,
+	// Include related information, province by default
+	includes, err := parseIncludes(c.Query("include"), districtRelations, defaultDistrictIncludes)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
 	}
+	result := h.expandDistrict(district, includes)
+	result.Extent = h.dataService.GetDistrictExtent(district.ID)
 	
 	return c.JSON(APIResponse{
 		Status: "success",
@@ -237,14 +525,12 @@ func (h *LocationHandler) GetSubDistrictsByDistrictID(c *fiber.Ctx) error {
 	// Check if district exists
 	_, exists := h.dataService.GetDistrict(districtID)
 	if !exists {
-		return c.Status(404).JSON(APIResponse{
-			Status: "error",
-			Error:  "District not found",
-		})
+		return h.notFound(c, LevelDistrict, districtID, "District not found")
 	}
 	
 	subDistricts := h.dataService.GetSubDistrictsByDistrict(districtID)
 	search := strings.ToLower(c.Query("search"))
+	matcher := newSearchMatcher(search, c.QueryBool("ignore_tones"))
 	zipCodeStr := c.Query("zip_code")
 	
 	// Filter by zip code if provided
@@ -265,21 +551,76 @@ func (h *LocationHandler) GetSubDistrictsByDistrictID(c *fiber.Ctx) error {
 		subDistricts = filteredSubDistricts
 	}
 	
-	// Filter by search term if provided
-	if search != "" {
+	// Filter by search term if provided, ranking by similarity instead of
+	// substring matching when fuzzy is requested
+	var scores map[int]float64
+	if search != "" && c.QueryBool("fuzzy") {
+		subDistricts, scores = fuzzySubDistricts(subDistricts, search, matcher.ignoreTones)
+	} else if search != "" {
 		filteredSubDistricts := make([]SubDistrict, 0)
 		for _, subDistrict := range subDistricts {
-			if strings.Contains(strings.ToLower(subDistrict.NameTH), search) ||
-				strings.Contains(strings.ToLower(subDistrict.NameEN), search) {
+			if matcher.matches(LevelSubDistrict, subDistrict.NameTH, subDistrict.NameEN) {
 				filteredSubDistricts = append(filteredSubDistricts, subDistrict)
 			}
 		}
 		subDistricts = filteredSubDistricts
 	}
 	
+	// Apply sort order if provided, keeping fuzzy scores as the last key
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorter := applySort[SubDistrict]
+		if scores != nil {
+			sorter = applyRankedSort[SubDistrict]
+		}
+		sorted, err := sorter(subDistricts, sortParam, subDistrictSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		subDistricts = sorted
+	}
+	
+	// Send the full filtered list as a spreadsheet or GeoJSON if requested
+	format, err := outputFormat(c, FormatCSV, FormatXLSX, FormatGeoJSON)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if format == FormatGeoJSON {
+		return c.JSON(h.subDistrictFeatures(subDistricts), mimeGeoJSON)
+	}
+	if format != "" {
+		return sendTable(c, format, "subdistricts", h.subDistrictTable(subDistricts, c.QueryBool("parents")))
+	}
+	
 	// Add pagination
-	page, limit := getPaginationParams(c)
-	paginatedData, pagination := paginate(subDistricts, page, limit)
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(subDistricts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested, and attach fuzzy scores
+	includes, err := parseIncludes(c.Query("include"), subDistrictRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	expanded := h.expandSubDistricts(paginatedData.([]SubDistrict), includes)
+	if scores != nil {
+		paginatedData = scoredSubDistricts(expanded, scores)
+	} else {
+		paginatedData = expanded
+	}
 	
 	return c.JSON(PaginatedResponse{
 		Status:     "success",
@@ -292,6 +633,7 @@ func (h *LocationHandler) GetSubDistrictsByDistrictID(c *fiber.Ctx) error {
 func (h *LocationHandler) GetSubDistricts(c *fiber.Ctx) error {
 	districtIDStr := c.Query("district_id")
 	search := strings.ToLower(c.Query("search"))
+	matcher := newSearchMatcher(search, c.QueryBool("ignore_tones"))
 	zipCodeStr := c.Query("zip_code")
 	
 	subDistricts := h.dataService.GetSubDistricts()
@@ -317,30 +659,90 @@ func (h *LocationHandler) GetSubDistricts(c *fiber.Ctx) error {
 				Error:  "Invalid zip_code parameter",
 			})
 		}
-		filteredSubDistricts := make([]SubDistrict, 0)
-		for _, subDistrict := range subDistricts {
-			if subDistrict.ZipCode == zipCode {
-				filteredSubDistricts = append(filteredSubDistricts, subDistrict)
+		if districtIDStr == "" {
+			// Use the zip code index instead of scanning every sub-district
+			subDistricts = h.dataService.GetSubDistrictsByZipCode(zipCode)
+		} else {
+			filteredSubDistricts := make([]SubDistrict, 0)
+			for _, subDistrict := range subDistricts {
+				if subDistrict.ZipCode == zipCode {
+					filteredSubDistricts = append(filteredSubDistricts, subDistrict)
+				}
 			}
+			subDistricts = filteredSubDistricts
 		}
-		subDistricts = filteredSubDistricts
 	}
 	
-	// Filter by search term if provided
-	if search != "" {
+	// Filter by search term if provided, ranking by similarity instead of
+	// substring matching when fuzzy is requested
+	var scores map[int]float64
+	if search != "" && c.QueryBool("fuzzy") {
+		subDistricts, scores = fuzzySubDistricts(subDistricts, search, matcher.ignoreTones)
+	} else if search != "" {
 		filteredSubDistricts := make([]SubDistrict, 0)
 		for _, subDistrict := range subDistricts {
-			if strings.Contains(strings.ToLower(subDistrict.NameTH), search) ||
-				strings.Contains(strings.ToLower(subDistrict.NameEN), search) {
+			if matcher.matches(LevelSubDistrict, subDistrict.NameTH, subDistrict.NameEN) {
 				filteredSubDistricts = append(filteredSubDistricts, subDistrict)
 			}
 		}
 		subDistricts = filteredSubDistricts
 	}
 	
+	// Apply sort order if provided, keeping fuzzy scores as the last key
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorter := applySort[SubDistrict]
+		if scores != nil {
+			sorter = applyRankedSort[SubDistrict]
+		}
+		sorted, err := sorter(subDistricts, sortParam, subDistrictSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		subDistricts = sorted
+	}
+	
+	// Send the full filtered list as a spreadsheet or GeoJSON if requested
+	format, err := outputFormat(c, FormatCSV, FormatXLSX, FormatGeoJSON)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if format == FormatGeoJSON {
+		return c.JSON(h.subDistrictFeatures(subDistricts), mimeGeoJSON)
+	}
+	if format != "" {
+		return sendTable(c, format, "subdistricts", h.subDistrictTable(subDistricts, c.QueryBool("parents")))
+	}
+	
 	// Add pagination
-	page, limit := getPaginationParams(c)
-	paginatedData, pagination := paginate(subDistricts, page, limit)
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(subDistricts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested, and attach fuzzy scores
+	includes, err := parseIncludes(c.Query("include"), subDistrictRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	expanded := h.expandSubDistricts(paginatedData.([]SubDistrict), includes)
+	if scores != nil {
+		paginatedData = scoredSubDistricts(expanded, scores)
+	} else {
+		paginatedData = expanded
+	}
 	
 	return c.JSON(PaginatedResponse{
 		Status:     "success",
@@ -362,21 +764,18 @@ func (h *LocationHandler) GetSubDistrictByID(c *fiber.Ctx) error {
 	
 	subDistrict, exists := h.dataService.GetSubDistrict(id)
 	if !exists {
-		return c.Status(404).JSON(APIResponse{
-			Status: "error",
-			Error:  "Sub-district not found",
-		})
+		return h.notFound(c, LevelSubDistrict, id, "Sub-district not found")
 	}
 	
-	// Include district and province information
-	district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
-	province, _ := h.dataService.GetProvince(district.ProvinceID)
-	
-	result := SubDistrictWithDistrict{
-		SubDistrict: subDistrict,
-		District:    &district,
-		Province:    &province,
+	// Include related information, district and province by default
+	includes, err := parseIncludes(c.Query("include"), subDistrictRelations, defaultSubDistrictIncludes)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
 	}
+	result := h.expandSubDistrict(subDistrict, includes)
 	
 	return c.JSON(APIResponse{
 		Status: "success",
@@ -384,12 +783,702 @@ func (h *LocationHandler) GetSubDistrictByID(c *fiber.Ctx) error {
 	})
 }
 
-// Helper functions
-
-// getPaginationParams extracts pagination parameters from query string
-func getPaginationParams(c *fiber.Ctx) (page, limit int) {
-	page = 1
-	limit = 20
+// GetZipCode returns every sub-district sharing a zip code with its full hierarchy
+func (h *LocationHandler) GetZipCode(c *fiber.Ctx) error {
+	zipStr := c.Params("zip")
+	zipCode, err := strconv.Atoi(zipStr)
+	if err != nil || len(zipStr) != 5 {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid zip code",
+		})
+	}
+	
+	subDistricts := h.dataService.GetSubDistrictsByZipCode(zipCode)
+	if len(subDistricts) == 0 {
+		return c.Status(404).JSON(APIResponse{
+			Status: "error",
+			Error:  "Zip code not found",
+		})
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   h.withHierarchy(subDistricts),
+	})
+}
+
+// Autocomplete returns typed suggestions matching a query at any hierarchy level
+func (h *LocationHandler) Autocomplete(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "q parameter is required",
+		})
+	}
+	
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
+			limit = l
+		}
+	}
+	
+	suggestions := h.dataService.Autocomplete(query, limit, c.QueryBool("ignore_tones"))
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   suggestions,
+	})
+}
+
+// Export returns the whole dataset in a compact normalized format. The
+// version doubles as an ETag so clients can revalidate cheaply.
+func (h *LocationHandler) Export(c *fiber.Ctx) error {
+	export := h.dataService.GetExport(c.QueryBool("timestamps"))
+	body, encoding := export.encoded(c)
+	
+	// Each encoding is a distinct representation, so it gets its own ETag
+	etag := `"` + export.Version + `"`
+	if encoding != "" {
+		etag = `"` + export.Version + "-" + encoding + `"`
+	}
+	
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	c.Set(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	if encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, encoding)
+	}
+	return c.Send(body)
+}
+
+// GetExportVersion returns the current export version without the data
+func (h *LocationHandler) GetExportVersion(c *fiber.Ctx) error {
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   h.dataService.GetExportVersion(),
+	})
+}
+
+// GetChanges returns the entities created, updated or deleted since a timestamp
+func (h *LocationHandler) GetChanges(c *fiber.Ctx) error {
+	var since time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  "Invalid since parameter: expected an RFC3339 timestamp",
+			})
+		}
+		since = parsed
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   h.dataService.GetChanges(since),
+	})
+}
+
+// Batch resolves lists of province, district and sub-district IDs and zip codes in one call
+func (h *LocationHandler) Batch(c *fiber.Ctx) error {
+	var req BatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid request body",
+		})
+	}
+	
+	size := len(req.ProvinceIDs) + len(req.DistrictIDs) + len(req.SubDistrictIDs) + len(req.ZipCodes)
+	if size == 0 {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "At least one ID or zip code is required",
+		})
+	}
+	if size > h.config.MaxBatchSize {
+		return c.Status(413).JSON(APIResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Batch size %d exceeds the maximum of %d", size, h.config.MaxBatchSize),
+		})
+	}
+	
+	result := BatchResponse{
+		Provinces:    make([]BatchItem, 0, len(req.ProvinceIDs)),
+		Districts:    make([]BatchItem, 0, len(req.DistrictIDs)),
+		SubDistricts: make([]BatchItem, 0, len(req.SubDistrictIDs)),
+		ZipCodes:     make([]BatchZipCodeItem, 0, len(req.ZipCodes)),
+	}
+	
+	for _, id := range req.ProvinceIDs {
+		item := BatchItem{ID: id}
+		if province, exists := h.dataService.GetProvince(id); exists {
+			geography, _ := h.dataService.GetGeography(province.GeographyID)
+			item.Found = true
+			item.Data = ProvinceWithGeography{
+				Province:  province,
+				Geography: &geography,
+			}
+		}
+		result.Provinces = append(result.Provinces, item)
+	}
+	
+	for _, id := range req.DistrictIDs {
+		item := BatchItem{ID: id}
+		if district, exists := h.dataService.GetDistrict(id); exists {
+			province, _ := h.dataService.GetProvince(district.ProvinceID)
+			item.Found = true
+			item.Data = DistrictWithProvince{
+				District: district,
+				Province: &province,
+			}
+		}
+		result.Districts = append(result.Districts, item)
+	}
+	
+	for _, id := range req.SubDistrictIDs {
+		item := BatchItem{ID: id}
+		if subDistrict, exists := h.dataService.GetSubDistrict(id); exists {
+			district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
+			province, _ := h.dataService.GetProvince(district.ProvinceID)
+			item.Found = true
+			item.Data = SubDistrictWithDistrict{
+				SubDistrict: subDistrict,
+				District:    &district,
+				Province:    &province,
+			}
+		}
+		result.SubDistricts = append(result.SubDistricts, item)
+	}
+	
+	for _, zipCode := range req.ZipCodes {
+		item := BatchZipCodeItem{ZipCode: zipCode}
+		if subDistricts := h.dataService.GetSubDistrictsByZipCode(zipCode); len(subDistricts) > 0 {
+			item.Found = true
+			item.Data = h.withHierarchy(subDistricts)
+		}
+		result.ZipCodes = append(result.ZipCodes, item)
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// ParseAddress extracts and resolves the administrative parts of a free-form address
+func (h *LocationHandler) ParseAddress(c *fiber.Ctx) error {
+	var req AddressParseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid request body",
+		})
+	}
+	
+	address := strings.TrimSpace(req.Address)
+	if address == "" || len(address) > maxAddressLength {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid address parameter",
+		})
+	}
+	
+	result := h.dataService.ParseAddress(address)
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// ValidateAddress checks that the components of an address tuple agree with each other
+func (h *LocationHandler) ValidateAddress(c *fiber.Ctx) error {
+	var req AddressValidateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid request body",
+		})
+	}
+	
+	if req.SubDistrictID == 0 && req.SubDistrict == "" && req.DistrictID == 0 && req.District == "" &&
+		req.ProvinceID == 0 && req.Province == "" && req.ZipCode == 0 {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "At least one address component is required",
+		})
+	}
+	
+	result := h.dataService.ValidateAddress(req)
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// ReverseGeocode returns the sub-district nearest to a lat/long coordinate
+func (h *LocationHandler) ReverseGeocode(c *fiber.Ctx) error {
+	lat, long, err := parseCoordinates(c.Query("lat"), c.Query("long"))
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	subDistrict, distance, found := h.dataService.GetNearestSubDistrict(lat, long)
+	if !found {
+		return c.Status(404).JSON(APIResponse{
+			Status: "error",
+			Error:  "No sub-district with coordinates found",
+		})
+	}
+	
+	// Include district and province information
+	district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
+	province, _ := h.dataService.GetProvince(district.ProvinceID)
+	
+	result := ReverseGeocodeResult{
+		SubDistrictWithDistrict: SubDistrictWithDistrict{
+			SubDistrict: subDistrict,
+			District:    &district,
+			Province:    &province,
+		},
+		DistanceMeters: distance,
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   result,
+	})
+}
+
+// GetSubDistrictsNearby returns sub-districts within a radius of a coordinate
+func (h *LocationHandler) GetSubDistrictsNearby(c *fiber.Ctx) error {
+	lat, long, err := parseCoordinates(c.Query("lat"), c.Query("long"))
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	radius, err := strconv.ParseFloat(c.Query("radius_m"), 64)
+	if err != nil || radius <= 0 || radius > maxNearbyRadiusMeters {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid radius_m parameter",
+		})
+	}
+	
+	subDistricts := h.dataService.GetSubDistrictsNearby(lat, long, radius)
+	
+	// Apply sort order if provided
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorted, err := applySort(subDistricts, sortParam, subDistrictSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		subDistricts = sorted
+	}
+	
+	// Add pagination
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(subDistricts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested
+	includes, err := parseIncludes(c.Query("include"), subDistrictRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	paginatedData = h.expandNearby(paginatedData.([]SubDistrictWithDistance), includes)
+	
+	return c.JSON(PaginatedResponse{
+		Status:     "success",
+		Data:       paginatedData,
+		Pagination: pagination,
+	})
+}
+
+// GetSubDistrictsWithin returns sub-districts inside a bounding box
+func (h *LocationHandler) GetSubDistrictsWithin(c *fiber.Ctx) error {
+	minLong, minLat, maxLong, maxLat, err := parseBBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	subDistricts := h.dataService.GetSubDistrictsWithin(minLong, minLat, maxLong, maxLat)
+	
+	// Apply sort order if provided
+	if sortParam := c.Query("sort"); sortParam != "" {
+		sorted, err := applySort(subDistricts, sortParam, subDistrictSortFields)
+		if err != nil {
+			return c.Status(400).JSON(APIResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		subDistricts = sorted
+	}
+	
+	// Add pagination
+	params := getPaginationParams(c)
+	paginatedData, pagination, err := paginate(subDistricts, params)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	// Embed related information if requested
+	includes, err := parseIncludes(c.Query("include"), subDistrictRelations, nil)
+	if err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	paginatedData = h.expandNearby(paginatedData.([]SubDistrictWithDistance), includes)
+	
+	return c.JSON(PaginatedResponse{
+		Status:     "success",
+		Data:       paginatedData,
+		Pagination: pagination,
+	})
+}
+
+// GetDistance returns the great-circle distance between two locations
+func (h *LocationHandler) GetDistance(c *fiber.Ctx) error {
+	fromRef, toRef := c.Query("from"), c.Query("to")
+	if fromRef == "" || toRef == "" {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "from and to parameters are required",
+		})
+	}
+	
+	from, err := h.dataService.ResolveLocation(fromRef)
+	if err != nil {
+		return c.Status(locationErrorStatus(err)).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	to, err := h.dataService.ResolveLocation(toRef)
+	if err != nil {
+		return c.Status(locationErrorStatus(err)).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	distance := pointDistance(from, to)
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data: DistanceResult{
+			From:           from,
+			To:             to,
+			DistanceMeters: distance,
+			DistanceKm:     distance / 1000,
+		},
+	})
+}
+
+// GetDistanceMatrix returns the distances from every origin to every destination
+func (h *LocationHandler) GetDistanceMatrix(c *fiber.Ctx) error {
+	var req DistanceMatrixRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "Invalid request body",
+		})
+	}
+	
+	if len(req.Origins) == 0 || len(req.Destinations) == 0 {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "At least one origin and one destination are required",
+		})
+	}
+	if len(req.Origins) > h.config.MaxMatrixSize || len(req.Destinations) > h.config.MaxMatrixSize {
+		return c.Status(413).JSON(APIResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("A distance matrix allows at most %d origins and %d destinations", h.config.MaxMatrixSize, h.config.MaxMatrixSize),
+		})
+	}
+	
+	resolve := func(refs []string) ([]LocationPoint, error) {
+		points := make([]LocationPoint, 0, len(refs))
+		for _, ref := range refs {
+			point, err := h.dataService.ResolveLocation(ref)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, point)
+		}
+		return points, nil
+	}
+	
+	matrix := DistanceMatrix{}
+	var err error
+	if matrix.Origins, err = resolve(req.Origins); err == nil {
+		matrix.Destinations, err = resolve(req.Destinations)
+	}
+	if err != nil {
+		return c.Status(locationErrorStatus(err)).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	matrix.DistanceMeters = make([][]float64, len(matrix.Origins))
+	for i, origin := range matrix.Origins {
+		matrix.DistanceMeters[i] = make([]float64, len(matrix.Destinations))
+		for j, destination := range matrix.Destinations {
+			matrix.DistanceMeters[i][j] = pointDistance(origin, destination)
+		}
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   matrix,
+	})
+}
+
+// GetCoordinateReport lists sub-districts whose coordinates are missing or estimated
+func (h *LocationHandler) GetCoordinateReport(c *fiber.Ctx) error {
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   h.dataService.GetCoordinateReport(),
+	})
+}
+
+// VersionsHandler routes requests to the selected dataset version and
+// serves the endpoints that span all versions
+type VersionsHandler struct {
+	versions *DatasetVersions
+	config   HandlerConfig
+}
+
+// NewVersionsHandler creates a new VersionsHandler
+func NewVersionsHandler(versions *DatasetVersions, config HandlerConfig) *VersionsHandler {
+	return &VersionsHandler{
+		versions: versions,
+		config:   config,
+	}
+}
+
+// Handle wraps a LocationHandler method so it serves the dataset version
+// selected by the version query parameter or the X-Dataset-Version header,
+// including deleted records when include_deleted is set
+func (vh *VersionsHandler) Handle(fn func(*LocationHandler, *fiber.Ctx) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		version := c.Query("version")
+		if version == "" {
+			version = c.Get(datasetVersionHeader)
+		}
+		if version == "" {
+			version = vh.versions.Default()
+		}
+		
+		dataService, exists := vh.versions.Get(version)
+		if !exists {
+			return c.Status(404).JSON(APIResponse{
+				Status: "error",
+				Error:  fmt.Sprintf("Dataset version not found: %s", version),
+			})
+		}
+		
+		// Serve the whole request from one dataset even if a reload swaps it
+		dataService = dataService.Snapshot()
+		if c.QueryBool("include_deleted") {
+			dataService = dataService.IncludingDeleted()
+		}
+		
+		c.Set(datasetVersionHeader, version)
+		return fn(NewLocationHandler(dataService, vh.config), c)
+	}
+}
+
+// GetVersions lists the loaded dataset versions
+func (vh *VersionsHandler) GetVersions(c *fiber.Ctx) error {
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   vh.versions.List(),
+	})
+}
+
+// GetVersionDiff compares two dataset versions; to defaults to the default version
+func (vh *VersionsHandler) GetVersionDiff(c *fiber.Ctx) error {
+	from := c.Query("from")
+	if from == "" {
+		return c.Status(400).JSON(APIResponse{
+			Status: "error",
+			Error:  "from parameter is required",
+		})
+	}
+	to := c.Query("to", vh.versions.Default())
+	
+	diff, err := vh.versions.Diff(from, to)
+	if err != nil {
+		return c.Status(404).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	
+	return c.JSON(APIResponse{
+		Status: "success",
+		Data:   diff,
+	})
+}
+
+// ReloadData reloads every dataset version; versions that fail to reload
+// keep serving their previous data
+func (vh *VersionsHandler) ReloadData(c *fiber.Ctx) error {
+	err := vh.versions.ReloadAll("admin request")
+	if errors.Is(err, errReloadInProgress) {
+		return c.Status(409).JSON(APIResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(422).JSON(APIResponse{
+			Status: "error",
+			Data:   vh.versions.List(),
+			Error:  err.Error(),
+		})
+	}
+	
+	return c.JSON(APIResponse{
+		Status:  "success",
+		Message: "Data reloaded",
+		Data:    vh.versions.List(),
+	})
+}
+
+// Helper functions
+
+// notFound responds 410 Gone with the deletion time for deleted records and
+// 404 otherwise
+func (h *LocationHandler) notFound(c *fiber.Ctx, level string, id int, message string) error {
+	if deletedAt, deleted := h.dataService.GetDeletedAt(level, id); deleted {
+		return c.Status(410).JSON(APIResponse{
+			Status: "error",
+			Data:   DeletedRecord{Level: level, ID: id, DeletedAt: deletedAt},
+			Error:  fmt.Sprintf("%s %d was deleted at %s", levelNames[level], id, deletedAt.Format(time.RFC3339)),
+		})
+	}
+	return c.Status(404).JSON(APIResponse{
+		Status: "error",
+		Error:  message,
+	})
+}
+
+// withHierarchy embeds district, province and geography information in each sub-district
+func (h *LocationHandler) withHierarchy(subDistricts []SubDistrict) []SubDistrictWithHierarchy {
+	results := make([]SubDistrictWithHierarchy, 0, len(subDistricts))
+	for _, subDistrict := range subDistricts {
+		district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
+		province, _ := h.dataService.GetProvince(district.ProvinceID)
+		geography, _ := h.dataService.GetGeography(province.GeographyID)
+		results = append(results, SubDistrictWithHierarchy{
+			SubDistrict: subDistrict,
+			District:    &district,
+			Province:    &province,
+			Geography:   &geography,
+		})
+	}
+	return results
+}
+
+// parseCoordinates parses and validates lat/long query values
+func parseCoordinates(latStr, longStr string) (lat, long float64, err error) {
+	if latStr == "" || longStr == "" {
+		return 0, 0, errors.New("lat and long parameters are required")
+	}
+	
+	lat, err = strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return 0, 0, errors.New("Invalid lat parameter")
+	}
+	
+	long, err = strconv.ParseFloat(longStr, 64)
+	if err != nil {
+		return 0, 0, errors.New("Invalid long parameter")
+	}
+	
+	if !validCoordinates(lat, long) {
+		return 0, 0, errors.New("lat/long out of range")
+	}
+	
+	return lat, long, nil
+}
+
+// parseBBox parses a "minLon,minLat,maxLon,maxLat" bounding box
+func parseBBox(bbox string) (minLong, minLat, maxLong, maxLat float64, err error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return 0, 0, 0, 0, errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	
+	values := make([]float64, 4)
+	for i, part := range parts {
+		values[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return 0, 0, 0, 0, errors.New("Invalid bbox parameter")
+		}
+	}
+	
+	minLong, minLat, maxLong, maxLat = values[0], values[1], values[2], values[3]
+	if !validCoordinates(minLat, minLong) || !validCoordinates(maxLat, maxLong) ||
+		minLong > maxLong || minLat > maxLat {
+		return 0, 0, 0, 0, errors.New("Invalid bbox parameter")
+	}
+	
+	return minLong, minLat, maxLong, maxLat, nil
+}
+
+// pageParams selects a page of a list by offset or by cursor
+type pageParams struct {
+	Page    int
+	Limit   int
+	Cursor  *pageCursor
+	Path    string
+	Filters map[string]string
+}
+
+// getPaginationParams extracts pagination parameters from query string
+func getPaginationParams(c *fiber.Ctx) pageParams {
+	page := 1
+	limit := 20
 	
 	if pageStr := c.Query("page"); pageStr != "" {
 		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
@@ -403,78 +1492,84 @@ func getPaginationParams(c *fiber.Ctx) (page, limit int) {
 		}
 	}
 	
-	return page, limit
+	params := pageParams{
+		Page:    page,
+		Limit:   limit,
+		Path:    c.Path(),
+		Filters: activeFilters(c),
+	}
+	
+	// Bind cursors to the dataset version being served
+	if version := c.GetRespHeader(datasetVersionHeader); version != "" {
+		params.Filters[versionFilter] = version
+	}
+	
+	// Cursor decoded by CursorMiddleware takes precedence over page
+	if cursor, ok := c.Locals(cursorLocalsKey).(pageCursor); ok {
+		params.Cursor = &cursor
+	}
+	
+	return params
 }
 
 // paginate applies pagination to a slice of data
-func paginate(data interface{}, page, limit int) (interface{}, Pagination) {
-	var total int
-	var result interface{}
-	
+func paginate(data interface{}, params pageParams) (interface{}, Pagination, error) {
 	switch v := data.(type) {
 	case []Geography:
-		total = len(v)
-		start := (page - 1) * limit
-		end := start + limit
-		if start > total {
-			result = []Geography{}
-		} else {
-			if end > total {
-				end = total
-			}
-			result = v[start:end]
-		}
+		return pageOf(v, params)
 	case []Province:
-		total = len(v)
-		start := (page - 1) * limit
-		end := start + limit
-		if start > total {
-			result = []Province{}
-		} else {
-			if end > total {
-				end = total
-			}
-			result = v[start:end]
-		}
+		return pageOf(v, params)
 	case []District:
-		total = len(v)
-		start := (page - 1) * limit
-		end := start + limit
-		if start > total {
-			result = []District{}
-		} else {
-			if end > total {
-				end = total
-			}
-			result = v[start:end]
-		}
+		return pageOf(v, params)
 	case []SubDistrict:
-		total = len(v)
-		start := (page - 1) * limit
-		end := start + limit
-		if start > total {
-			result = []SubDistrict{}
-		} else {
-			if end > total {
-				end = total
-			}
-			result = v[start:end]
-		}
+		return pageOf(v, params)
+	case []SubDistrictWithDistance:
+		return pageOf(v, params)
 	default:
-		result = data
+		return data, Pagination{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			TotalPages: 1,
+		}, nil
+	}
+}
+
+// pageOf returns one page of a slice with its pagination metadata, including
+// a cursor for the following page when more items remain
+func pageOf[T keyed](v []T, params pageParams) (interface{}, Pagination, error) {
+	total := len(v)
+	
+	start := (params.Page - 1) * params.Limit
+	if params.Cursor != nil {
+		var err error
+		start, err = cursorStart(v, params.Cursor.AfterID)
+		if err != nil {
+			return nil, Pagination{}, err
+		}
 	}
+	start = min(start, total)
+	end := min(start+params.Limit, total)
 	
-	totalPages := (total + limit - 1) / limit
+	totalPages := (total + params.Limit - 1) / params.Limit
 	if totalPages == 0 {
 		totalPages = 1
 	}
 	
 	pagination := Pagination{
-		Page:       page,
-		Limit:      limit,
+		Page:       start/params.Limit + 1,
+		Limit:      params.Limit,
 		Total:      total,
 		TotalPages: totalPages,
 	}
 	
-	return result, pagination
+	if end < total && end > 0 {
+		pagination.NextCursor = encodeCursor(pageCursor{
+			Path:    params.Path,
+			AfterID: v[end-1].key(),
+			Limit:   params.Limit,
+			Filters: params.Filters,
+		})
+	}
+	
+	return v[start:end], pagination, nil
 }
\ No newline at end of file
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Relations that can be requested with the include parameter
+const (
+	IncludeDistrict  = "district"
+	IncludeProvince  = "province"
+	IncludeGeography = "geography"
+	IncludeChildren  = "children"
+)
+
+// includeSet is the set of relations to embed in a response
+type includeSet map[string]bool
+
+// Relations that apply to each level
+var (
+	geographyRelations   = includeSet{IncludeChildren: true}
+	provinceRelations    = includeSet{IncludeGeography: true, IncludeChildren: true}
+	districtRelations    = includeSet{IncludeProvince: true, IncludeGeography: true, IncludeChildren: true}
+	subDistrictRelations = includeSet{IncludeDistrict: true, IncludeProvince: true, IncludeGeography: true}
+)
+
+// Default relations embedded by the detail endpoints when include is not given
+var (
+	defaultProvinceIncludes    = includeSet{IncludeGeography: true}
+	defaultDistrictIncludes    = includeSet{IncludeProvince: true}
+	defaultSubDistrictIncludes = includeSet{IncludeDistrict: true, IncludeProvince: true}
+)
+
+// parseIncludes parses a comma-separated include parameter, rejecting
+// relations the level does not have and returning fallback when the
+// parameter is empty
+func parseIncludes(param string, relations, fallback includeSet) (includeSet, error) {
+	if strings.TrimSpace(param) == "" {
+		return fallback, nil
+	}
+
+	includes := make(includeSet)
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "":
+			continue
+		case IncludeDistrict, IncludeProvince, IncludeGeography, IncludeChildren:
+			if !relations[part] {
+				return nil, fmt.Errorf("Include value does not apply to this endpoint: %s", part)
+			}
+			includes[part] = true
+		default:
+			return nil, fmt.Errorf("Invalid include value: %s", part)
+		}
+	}
+	return includes, nil
+}
+
+// expandGeography embeds the requested relations of a geography
+func (h *LocationHandler) expandGeography(geography Geography, includes includeSet) GeographyWithExtent {
+	result := GeographyWithExtent{Geography: geography}
+	if includes[IncludeChildren] {
+		result.Provinces = h.dataService.GetProvincesByGeography(geography.ID)
+	}
+	return result
+}
+
+// expandProvince embeds the requested relations of a province
+func (h *LocationHandler) expandProvince(province Province, includes includeSet) ProvinceWithGeography {
+	result := ProvinceWithGeography{Province: province}
+	if includes[IncludeGeography] {
+		if geography, exists := h.dataService.GetGeography(province.GeographyID); exists {
+			result.Geography = &geography
+		}
+	}
+	if includes[IncludeChildren] {
+		result.Districts = h.dataService.GetDistrictsByProvince(province.ID)
+	}
+	return result
+}
+
+// expandDistrict embeds the requested relations of a district
+func (h *LocationHandler) expandDistrict(district District, includes includeSet) DistrictWithProvince {
+	result := DistrictWithProvince{District: district}
+	if includes[IncludeProvince] || includes[IncludeGeography] {
+		province, exists := h.dataService.GetProvince(district.ProvinceID)
+		if exists && includes[IncludeProvince] {
+			result.Province = &province
+		}
+		if geography, exists := h.dataService.GetGeography(province.GeographyID); exists && includes[IncludeGeography] {
+			result.Geography = &geography
+		}
+	}
+	if includes[IncludeChildren] {
+		result.SubDistricts = h.dataService.GetSubDistrictsByDistrict(district.ID)
+	}
+	return result
+}
+
+// expandSubDistrict embeds the requested relations of a sub-district
+func (h *LocationHandler) expandSubDistrict(subDistrict SubDistrict, includes includeSet) SubDistrictWithHierarchy {
+	result := SubDistrictWithHierarchy{SubDistrict: subDistrict}
+	if includes[IncludeDistrict] || includes[IncludeProvince] || includes[IncludeGeography] {
+		district, exists := h.dataService.GetDistrict(subDistrict.DistrictID)
+		if exists && includes[IncludeDistrict] {
+			result.District = &district
+		}
+		province, exists := h.dataService.GetProvince(district.ProvinceID)
+		if exists && includes[IncludeProvince] {
+			result.Province = &province
+		}
+		if geography, exists := h.dataService.GetGeography(province.GeographyID); exists && includes[IncludeGeography] {
+			result.Geography = &geography
+		}
+	}
+	return result
+}
+
+// expandGeographies embeds the requested relations of each geography
+func (h *LocationHandler) expandGeographies(geographies []Geography, includes includeSet) []GeographyWithExtent {
+	results := make([]GeographyWithExtent, 0, len(geographies))
+	for _, geography := range geographies {
+		results = append(results, h.expandGeography(geography, includes))
+	}
+	return results
+}
+
+// expandProvinces embeds the requested relations of each province
+func (h *LocationHandler) expandProvinces(provinces []Province, includes includeSet) []ProvinceWithGeography {
+	results := make([]ProvinceWithGeography, 0, len(provinces))
+	for _, province := range provinces {
+		results = append(results, h.expandProvince(province, includes))
+	}
+	return results
+}
+
+// expandDistricts embeds the requested relations of each district
+func (h *LocationHandler) expandDistricts(districts []District, includes includeSet) []DistrictWithProvince {
+	results := make([]DistrictWithProvince, 0, len(districts))
+	for _, district := range districts {
+		results = append(results, h.expandDistrict(district, includes))
+	}
+	return results
+}
+
+// expandSubDistricts embeds the requested relations of each sub-district
+func (h *LocationHandler) expandSubDistricts(subDistricts []SubDistrict, includes includeSet) []SubDistrictWithHierarchy {
+	results := make([]SubDistrictWithHierarchy, 0, len(subDistricts))
+	for _, subDistrict := range subDistricts {
+		results = append(results, h.expandSubDistrict(subDistrict, includes))
+	}
+	return results
+}
+
+// expandNearby embeds the requested relations of each sub-district found by a
+// geo query, keeping its distance
+func (h *LocationHandler) expandNearby(subDistricts []SubDistrictWithDistance, includes includeSet) []NearbySubDistrict {
+	results := make([]NearbySubDistrict, 0, len(subDistricts))
+	for _, subDistrict := range subDistricts {
+		results = append(results, NearbySubDistrict{
+			SubDistrictWithHierarchy: h.expandSubDistrict(subDistrict.SubDistrict, includes),
+			DistanceMeters:           subDistrict.DistanceMeters,
+		})
+	}
+	return results
+}
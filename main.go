@@ -3,14 +3,22 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiffCommand(os.Args[2:]))
+	}
+
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "Thai Location API v1.0.0",
@@ -25,14 +33,26 @@ func main() {
 		AllowHeaders: "*",
 	}))
 
-	// Initialize data service
-	dataService, err := NewDataService("./data/raw")
+	// Initialize data services, one per dataset version directory
+	versions, err := NewDatasetVersions(getEnv("DATA_DIR", "./data"), getEnv("DATASET_VERSION", "raw"))
 	if err != nil {
 		log.Fatal("Failed to initialize data service:", err)
 	}
 
-	// Initialize handlers
-	handler := NewLocationHandler(dataService)
+	// Reload data on SIGHUP and, unless RELOAD_POLL_SECONDS is 0, when the files change
+	go versions.ReloadOnSignal()
+	if os.Getenv("RELOAD_POLL_SECONDS") != "0" {
+		interval := getEnvInt("RELOAD_POLL_SECONDS", 30)
+		go versions.WatchDataFiles(time.Duration(interval) * time.Second)
+	}
+
+	// Initialize handlers; route serves a LocationHandler method from the
+	// requested dataset version
+	handler := NewVersionsHandler(versions, HandlerConfig{
+		MaxBatchSize:  getEnvInt("BATCH_MAX_SIZE", 500),
+		MaxMatrixSize: getEnvInt("DISTANCE_MATRIX_MAX_SIZE", 100),
+	})
+	route := handler.Handle
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -42,25 +62,69 @@ func main() {
 		})
 	})
 
+	// Compress change sets outside FieldsMiddleware so fields are projected
+	// before the body is encoded
+	app.Use("/api/v1/changes", compress.New())
+
 	// API routes
 	api := app.Group("/api/v1")
+	api.Use(FieldsMiddleware)
+	
+	// Dataset version routes
+	api.Get("/versions", handler.GetVersions)
+	api.Get("/versions/diff", handler.GetVersionDiff)
 	
 	// Geography routes
-	api.Get("/geographies", handler.GetGeographies)
+	api.Get("/geographies", route((*LocationHandler).GetGeographies))
+	api.Get("/geographies/:id", route((*LocationHandler).GetGeographyByID))
 	
 	// Province routes
-	api.Get("/provinces", handler.GetProvinces)
-	api.Get("/provinces/:id", handler.GetProvinceByID)
-	api.Get("/provinces/:id/districts", handler.GetDistrictsByProvinceID)
+	api.Get("/provinces", CursorMiddleware, route((*LocationHandler).GetProvinces))
+	api.Get("/provinces/:id", route((*LocationHandler).GetProvinceByID))
+	api.Get("/provinces/:id/tree", route((*LocationHandler).GetProvinceTree))
+	api.Get("/provinces/:id/bounds", route((*LocationHandler).GetProvinceBounds))
+	api.Get("/provinces/:id/districts", CursorMiddleware, route((*LocationHandler).GetDistrictsByProvinceID))
 	
 	// District routes
-	api.Get("/districts", handler.GetDistricts)
-	api.Get("/districts/:id", handler.GetDistrictByID)
-	api.Get("/districts/:id/subdistricts", handler.GetSubDistrictsByDistrictID)
+	api.Get("/districts", CursorMiddleware, route((*LocationHandler).GetDistricts))
+	api.Get("/districts/:id", route((*LocationHandler).GetDistrictByID))
+	api.Get("/districts/:id/subdistricts", CursorMiddleware, route((*LocationHandler).GetSubDistrictsByDistrictID))
 	
 	// Sub-district (Tambon) routes
-	api.Get("/subdistricts", handler.GetSubDistricts)
-	api.Get("/subdistricts/:id", handler.GetSubDistrictByID)
+	api.Get("/subdistricts", CursorMiddleware, route((*LocationHandler).GetSubDistricts))
+	api.Get("/subdistricts/nearby", CursorMiddleware, route((*LocationHandler).GetSubDistrictsNearby))
+	api.Get("/subdistricts/within", CursorMiddleware, route((*LocationHandler).GetSubDistrictsWithin))
+	api.Get("/subdistricts/:id", route((*LocationHandler).GetSubDistrictByID))
+	
+	// Autocomplete routes
+	api.Get("/autocomplete", route((*LocationHandler).Autocomplete))
+	
+	// Zip code routes
+	api.Get("/zipcodes/:zip", route((*LocationHandler).GetZipCode))
+	
+	// Export routes
+	api.Get("/export", route((*LocationHandler).Export))
+	api.Get("/export/version", route((*LocationHandler).GetExportVersion))
+	
+	// Incremental sync routes
+	api.Get("/changes", route((*LocationHandler).GetChanges))
+	
+	// Batch routes
+	api.Post("/batch", route((*LocationHandler).Batch))
+	
+	// Address routes
+	api.Post("/address/parse", route((*LocationHandler).ParseAddress))
+	api.Post("/address/validate", route((*LocationHandler).ValidateAddress))
+	
+	// Geo routes
+	api.Get("/reverse", route((*LocationHandler).ReverseGeocode))
+	api.Get("/distance", route((*LocationHandler).GetDistance))
+	api.Post("/distance/matrix", route((*LocationHandler).GetDistanceMatrix))
+
+	// Admin routes
+	admin := api.Group("/admin", AdminMiddleware(os.Getenv("ADMIN_TOKEN")))
+	admin.Get("/coordinates", route((*LocationHandler).GetCoordinateReport))
+	admin.Post("/reload", handler.ReloadData)
 
 	// Get port from environment or default
 	port := os.Getenv("PORT")
@@ -72,4 +136,20 @@ func main() {
 	if err := app.Listen(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
+}
+
+// getEnv reads a string from the environment, falling back to a default
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt reads a positive integer from the environment, falling back to a default
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
 }
\ No newline at end of file
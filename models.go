@@ -34,16 +34,42 @@ type District struct {
 
 // SubDistrict represents a sub-district (tambon) in Thailand
 type SubDistrict struct {
-	ID         int        `json:"id"`
-	ZipCode    int        `json:"zip_code"`
-	NameTH     string     `json:"name_th"`
-	NameEN     string     `json:"name_en"`
-	DistrictID int        `json:"district_id"`
-	Lat        *float64   `json:"lat"`
-	Long       *float64   `json:"long"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
-	DeletedAt  *time.Time `json:"deleted_at"`
+	ID          int        `json:"id"`
+	ZipCode     int        `json:"zip_code"`
+	NameTH      string     `json:"name_th"`
+	NameEN      string     `json:"name_en"`
+	DistrictID  int        `json:"district_id"`
+	Lat         *float64   `json:"lat"`
+	Long        *float64   `json:"long"`
+	CoordSource string     `json:"coord_source,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+// DeletedRecord identifies a record that has been retired
+type DeletedRecord struct {
+	Level     string    `json:"level"`
+	ID        int       `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ScoredProvince is a province and its requested relations with a fuzzy search relevance score
+type ScoredProvince struct {
+	ProvinceWithGeography
+	Score float64 `json:"score"`
+}
+
+// ScoredDistrict is a district and its requested relations with a fuzzy search relevance score
+type ScoredDistrict struct {
+	DistrictWithProvince
+	Score float64 `json:"score"`
+}
+
+// ScoredSubDistrict is a sub-district and its requested relations with a fuzzy search relevance score
+type ScoredSubDistrict struct {
+	SubDistrictWithHierarchy
+	Score float64 `json:"score"`
 }
 
 // API Response structures
@@ -62,25 +88,247 @@ type PaginatedResponse struct {
 }
 
 type Pagination struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	Total      int `json:"total"`
-	TotalPages int `json:"total_pages"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Coordinate is a latitude/longitude pair
+type Coordinate struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+// BoundingBox is the smallest lat/long rectangle containing a set of points
+type BoundingBox struct {
+	MinLat  float64 `json:"min_lat"`
+	MinLong float64 `json:"min_long"`
+	MaxLat  float64 `json:"max_lat"`
+	MaxLong float64 `json:"max_long"`
+}
+
+// Extent is the centroid and bounding box of an area, computed from the
+// coordinates of its sub-districts
+type Extent struct {
+	Centroid Coordinate  `json:"centroid"`
+	BBox     BoundingBox `json:"bbox"`
+}
+
+// GeographyWithExtent is a geography with its computed extent
+type GeographyWithExtent struct {
+	Geography
+	*Extent
+	Provinces []Province `json:"provinces,omitempty"`
+}
+
+// ProvinceBounds is the computed extent of a province
+type ProvinceBounds struct {
+	ProvinceID int `json:"province_id"`
+	Extent
 }
 
 // Extended structures with relationships
 type ProvinceWithGeography struct {
 	Province
+	*Extent
 	Geography *Geography `json:"geography,omitempty"`
+	Districts []District `json:"districts,omitempty"`
 }
 
 type DistrictWithProvince struct {
 	District
-	Province *Province `json:"province,omitempty"`
+	*Extent
+	Province     *Province     `json:"province,omitempty"`
+	Geography    *Geography    `json:"geography,omitempty"`
+	SubDistricts []SubDistrict `json:"subdistricts,omitempty"`
 }
 
 type SubDistrictWithDistrict struct {
 	SubDistrict
 	District *District `json:"district,omitempty"`
 	Province *Province `json:"province,omitempty"`
-}
\ No newline at end of file
+}
+
+// ProvinceTree is a province with its nested districts and sub-districts
+type ProvinceTree struct {
+	Province
+	Districts []DistrictTree `json:"districts,omitempty"`
+}
+
+// DistrictTree is a district with its nested sub-districts
+type DistrictTree struct {
+	District
+	SubDistricts []SubDistrict `json:"subdistricts,omitempty"`
+}
+
+// SubDistrictWithHierarchy is a sub-district with every parent level embedded
+type SubDistrictWithHierarchy struct {
+	SubDistrict
+	District  *District  `json:"district,omitempty"`
+	Province  *Province  `json:"province,omitempty"`
+	Geography *Geography `json:"geography,omitempty"`
+}
+
+// SubDistrictWithDistance is a sub-district with its distance from a reference point
+type SubDistrictWithDistance struct {
+	SubDistrict
+	DistanceMeters float64 `json:"distance_m"`
+}
+
+// NearbySubDistrict is a sub-district found by a geo query with its requested
+// relations and its distance from the reference point
+type NearbySubDistrict struct {
+	SubDistrictWithHierarchy
+	DistanceMeters float64 `json:"distance_m"`
+}
+
+// ReverseGeocodeResult is the nearest sub-district to a coordinate
+type ReverseGeocodeResult struct {
+	SubDistrictWithDistrict
+	DistanceMeters float64 `json:"distance_m"`
+}
+
+// Suggestion is a typed autocomplete result at any hierarchy level
+type Suggestion struct {
+	Level     string `json:"level"`
+	ID        int    `json:"id"`
+	NameTH    string `json:"name_th"`
+	NameEN    string `json:"name_en"`
+	Display   string `json:"display"`
+	DisplayEN string `json:"display_en"`
+	ZipCode   int    `json:"zip_code,omitempty"`
+	Prefix    bool   `json:"prefix_match"`
+}
+
+// AddressParseRequest is the body of an address parse request
+type AddressParseRequest struct {
+	Address string `json:"address"`
+}
+
+// AddressComponents are the raw fragments extracted from a free-form address
+type AddressComponents struct {
+	SubDistrict string `json:"subdistrict,omitempty"`
+	District    string `json:"district,omitempty"`
+	Province    string `json:"province,omitempty"`
+	ZipCode     int    `json:"zip_code,omitempty"`
+}
+
+// AddressCandidate is one resolved interpretation of an address
+type AddressCandidate struct {
+	SubDistrictID int     `json:"subdistrict_id"`
+	DistrictID    int     `json:"district_id"`
+	ProvinceID    int     `json:"province_id"`
+	ZipCode       int     `json:"zip_code"`
+	Display       string  `json:"display"`
+	DisplayEN     string  `json:"display_en"`
+	Confidence    float64 `json:"confidence"`
+}
+
+// ParsedAddress is the result of parsing a free-form address
+type ParsedAddress struct {
+	Input      string             `json:"input"`
+	Components AddressComponents  `json:"components"`
+	Remainder  string             `json:"remainder"`
+	Match      *AddressCandidate  `json:"match,omitempty"`
+	Ambiguous  bool               `json:"ambiguous"`
+	Candidates []AddressCandidate `json:"candidates"`
+}
+
+// AddressValidateRequest is an address tuple to validate; each component may
+// be given by ID or by Thai/English name
+type AddressValidateRequest struct {
+	SubDistrictID int    `json:"subdistrict_id"`
+	SubDistrict   string `json:"subdistrict"`
+	DistrictID    int    `json:"district_id"`
+	District      string `json:"district"`
+	ProvinceID    int    `json:"province_id"`
+	Province      string `json:"province"`
+	ZipCode       int    `json:"zip_code"`
+}
+
+// FieldError reports a problem with one request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// AddressValidation is the result of validating an address tuple
+type AddressValidation struct {
+	Valid      bool              `json:"valid"`
+	Errors     []FieldError      `json:"errors"`
+	Suggestion *AddressCandidate `json:"suggestion,omitempty"`
+}
+
+// BatchRequest lists the entities to resolve in a single batch lookup
+type BatchRequest struct {
+	ProvinceIDs    []int `json:"province_ids"`
+	DistrictIDs    []int `json:"district_ids"`
+	SubDistrictIDs []int `json:"subdistrict_ids"`
+	ZipCodes       []int `json:"zip_codes"`
+}
+
+// BatchItem is one resolved ID in a batch response
+type BatchItem struct {
+	ID    int         `json:"id"`
+	Found bool        `json:"found"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// BatchZipCodeItem is one resolved zip code in a batch response
+type BatchZipCodeItem struct {
+	ZipCode int                        `json:"zip_code"`
+	Found   bool                       `json:"found"`
+	Data    []SubDistrictWithHierarchy `json:"data,omitempty"`
+}
+
+// BatchResponse holds the results of a batch lookup in request order
+type BatchResponse struct {
+	Provinces    []BatchItem        `json:"provinces"`
+	Districts    []BatchItem        `json:"districts"`
+	SubDistricts []BatchItem        `json:"subdistricts"`
+	ZipCodes     []BatchZipCodeItem `json:"zip_codes"`
+}
+
+// LocationPoint is a resolved location reference such as "subdistrict:100101"
+type LocationPoint struct {
+	Ref    string `json:"ref"`
+	Type   string `json:"type"`
+	ID     int    `json:"id,omitempty"`
+	NameTH string `json:"name_th,omitempty"`
+	NameEN string `json:"name_en,omitempty"`
+	Coordinate
+}
+
+// DistanceResult is the great-circle distance between two locations
+type DistanceResult struct {
+	From           LocationPoint `json:"from"`
+	To             LocationPoint `json:"to"`
+	DistanceMeters float64       `json:"distance_m"`
+	DistanceKm     float64       `json:"distance_km"`
+}
+
+// DistanceMatrixRequest lists the origins and destinations of a distance matrix
+type DistanceMatrixRequest struct {
+	Origins      []string `json:"origins"`
+	Destinations []string `json:"destinations"`
+}
+
+// DistanceMatrix holds distances in meters, indexed [origin][destination]
+type DistanceMatrix struct {
+	Origins        []LocationPoint `json:"origins"`
+	Destinations   []LocationPoint `json:"destinations"`
+	DistanceMeters [][]float64     `json:"distances_m"`
+}
+
+// DatasetStatus describes a loaded dataset version. Version is the content
+// hash of the export; Checksums are the SHA-256 of each data file.
+type DatasetStatus struct {
+	Name      string            `json:"name"`
+	Default   bool              `json:"default"`
+	LoadedAt  time.Time         `json:"loaded_at"`
+	Version   string            `json:"version"`
+	Checksums map[string]string `json:"checksums"`
+	Counts    map[string]int    `json:"counts"`
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// thaiAdminPrefixes are administrative prefixes users type before Thai names,
+// longest first so that "กิ่งอำเภอ" wins over "อำเภอ"
+var thaiAdminPrefixes = []string{
+	"กิ่งอำเภอ",
+	"จังหวัด",
+	"อำเภอ",
+	"ตำบล",
+	"แขวง",
+	"เขต",
+	"จ.",
+	"อ.",
+	"ต.",
+}
+
+// englishAdminPrefixes are romanized administrative prefixes, matched on word boundaries
+var englishAdminPrefixes = []string{
+	"king amphoe",
+	"changwat",
+	"khwaeng",
+	"tambon",
+	"amphoe",
+	"amphur",
+	"khet",
+}
+
+// levelAdminPrefixes are the Thai and romanized prefixes of each level, the
+// only ones stripped from stored names so a name such as "จ.ป.ร." is kept whole
+var levelAdminPrefixes = map[string]adminPrefixes{
+	LevelProvince:    {thai: []string{"จังหวัด", "จ."}, english: []string{"changwat"}},
+	LevelDistrict:    {thai: []string{"กิ่งอำเภอ", "อำเภอ", "เขต", "อ."}, english: []string{"king amphoe", "amphoe", "amphur", "khet"}},
+	LevelSubDistrict: {thai: []string{"ตำบล", "แขวง", "ต."}, english: []string{"khwaeng", "tambon"}},
+}
+
+// queryAdminPrefixes are stripped from queries, which may name any level
+var queryAdminPrefixes = adminPrefixes{thai: thaiAdminPrefixes, english: englishAdminPrefixes}
+
+// adminPrefixes is a set of Thai and romanized administrative prefixes
+type adminPrefixes struct {
+	thai    []string
+	english []string
+}
+
+// romanizedAliases maps common alternative spellings to the form used in NameEN
+var romanizedAliases = map[string]string{
+	"muang":  "mueang",
+	"meuang": "mueang",
+	"muéang": "mueang",
+}
+
+// isThaiToneMark reports whether r is a Thai tone mark (mai ek to mai chattawa)
+func isThaiToneMark(r rune) bool {
+	return r >= '่' && r <= '๋'
+}
+
+// normalizeSearchText prepares a query for matching; see normalizeText
+func normalizeSearchText(s string, ignoreTones bool) string {
+	return normalizeText(s, queryAdminPrefixes, ignoreTones)
+}
+
+// normalizeName prepares a stored name of the given level for matching,
+// stripping only that level's prefixes; see normalizeText
+func normalizeName(name, level string, ignoreTones bool) string {
+	return normalizeText(name, levelAdminPrefixes[level], ignoreTones)
+}
+
+// normalizeText lowercases, strips leading administrative prefixes, unifies
+// romanized spellings, optionally drops tone marks and removes all whitespace
+func normalizeText(s string, prefixes adminPrefixes, ignoreTones bool) string {
+	s = stripAdminPrefix(strings.ToLower(strings.TrimSpace(s)), prefixes)
+
+	words := strings.Fields(s)
+	for i, word := range words {
+		if alias, ok := romanizedAliases[word]; ok {
+			words[i] = alias
+		}
+	}
+	s = strings.Join(words, "")
+
+	if ignoreTones {
+		s = strings.Map(func(r rune) rune {
+			if isThaiToneMark(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+
+	return s
+}
+
+// stripAdminPrefix removes leading administrative prefixes from a lowercase name
+func stripAdminPrefix(s string, prefixes adminPrefixes) string {
+	for {
+		stripped := s
+		for _, prefix := range prefixes.thai {
+			if strings.HasPrefix(stripped, prefix) && len(stripped) > len(prefix) {
+				stripped = strings.TrimSpace(strings.TrimPrefix(stripped, prefix))
+				break
+			}
+		}
+		for _, prefix := range prefixes.english {
+			rest, ok := strings.CutPrefix(stripped, prefix)
+			if ok && rest != "" && !unicode.IsLetter([]rune(rest)[0]) {
+				stripped = strings.TrimLeft(rest, " .")
+				break
+			}
+		}
+		if stripped == s {
+			return s
+		}
+		s = stripped
+	}
+}
+
+// searchMatcher matches entity names against a normalized search query
+type searchMatcher struct {
+	query       string
+	ignoreTones bool
+}
+
+// newSearchMatcher creates a matcher for a raw search query
+func newSearchMatcher(search string, ignoreTones bool) searchMatcher {
+	return searchMatcher{
+		query:       normalizeSearchText(search, ignoreTones),
+		ignoreTones: ignoreTones,
+	}
+}
+
+// matches reports whether either name of an entity at the given level
+// contains the query after normalization
+func (m searchMatcher) matches(level, nameTH, nameEN string) bool {
+	return strings.Contains(normalizeName(nameTH, level, m.ignoreTones), m.query) ||
+		strings.Contains(normalizeName(nameEN, level, m.ignoreTones), m.query)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fieldTree is a parsed fields parameter; an empty subtree keeps the whole value
+type fieldTree map[string]fieldTree
+
+// parseFields parses "id,name_th,province.name_th" into a field tree
+func parseFields(param string) fieldTree {
+	tree := make(fieldTree)
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		for _, part := range strings.Split(path, ".") {
+			child, ok := node[part]
+			if !ok {
+				child = make(fieldTree)
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// project keeps only the requested keys of objects, applying the same tree
+// to every element of arrays
+func project(value interface{}, tree fieldTree) interface{} {
+	if len(tree) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = project(item, tree)
+		}
+		return v
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(tree))
+		for key, subtree := range tree {
+			if field, ok := v[key]; ok {
+				projected[key] = project(field, subtree)
+			}
+		}
+		return projected
+	default:
+		return value
+	}
+}
+
+// FieldsMiddleware trims the data of successful JSON responses down to the
+// paths listed in the fields query parameter. Responses with an ETag, such as
+// the export, are left whole so the ETag always names the full document, and
+// encoded bodies are left alone.
+func FieldsMiddleware(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		return err
+	}
+
+	param := c.Query("fields")
+	if param == "" || c.Response().StatusCode() != fiber.StatusOK ||
+		!strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) ||
+		len(c.Response().Header.Peek(fiber.HeaderETag)) > 0 ||
+		len(c.Response().Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+		return nil
+	}
+
+	// Decode into the response envelope so its key order is preserved
+	var body struct {
+		Status     string      `json:"status"`
+		Message    string      `json:"message,omitempty"`
+		Data       interface{} `json:"data,omitempty"`
+		Pagination *Pagination `json:"pagination,omitempty"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(c.Response().Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil || body.Status == "" {
+		return nil
+	}
+
+	body.Data = project(body.Data, parseFields(param))
+
+	return c.JSON(body)
+}
@@ -0,0 +1,84 @@
+package main
+
+// Coordinate statuses reported by the data-quality check
+const (
+	// CoordSourceEstimated marks coordinates imputed from sibling sub-districts
+	CoordSourceEstimated = "estimated"
+
+	// CoordStatusMissing marks sub-districts left without coordinates
+	CoordStatusMissing = "missing"
+)
+
+// CoordinateIssue is a sub-district whose coordinates are missing or estimated
+type CoordinateIssue struct {
+	ID         int      `json:"id"`
+	NameTH     string   `json:"name_th"`
+	NameEN     string   `json:"name_en"`
+	DistrictID int      `json:"district_id"`
+	ProvinceID int      `json:"province_id"`
+	Status     string   `json:"status"`
+	Lat        *float64 `json:"lat"`
+	Long       *float64 `json:"long"`
+	Siblings   int      `json:"siblings_used"`
+}
+
+// CoordinateReport summarizes sub-districts lacking source coordinates
+type CoordinateReport struct {
+	Total     int               `json:"total"`
+	Estimated int               `json:"estimated"`
+	Missing   int               `json:"missing"`
+	Records   []CoordinateIssue `json:"records"`
+}
+
+// imputeCoordinates fills missing sub-district coordinates with the mean of
+// the source coordinates of the other sub-districts in the same district,
+// marking them as estimated. Sub-districts whose district has no coordinates
+// at all stay missing. It returns a report of every affected record.
+func imputeCoordinates(subDistricts []SubDistrict, districtMap map[int]District) CoordinateReport {
+	siblings := make(map[int]*extentBuilder)
+	for _, subDistrict := range subDistricts {
+		if subDistrict.Lat == nil || subDistrict.Long == nil {
+			continue
+		}
+		if siblings[subDistrict.DistrictID] == nil {
+			siblings[subDistrict.DistrictID] = &extentBuilder{}
+		}
+		siblings[subDistrict.DistrictID].add(*subDistrict.Lat, *subDistrict.Long)
+	}
+
+	report := CoordinateReport{Records: make([]CoordinateIssue, 0)}
+	for i := range subDistricts {
+		subDistrict := &subDistricts[i]
+		if subDistrict.Lat != nil && subDistrict.Long != nil {
+			continue
+		}
+
+		issue := CoordinateIssue{
+			ID:         subDistrict.ID,
+			NameTH:     subDistrict.NameTH,
+			NameEN:     subDistrict.NameEN,
+			DistrictID: subDistrict.DistrictID,
+			ProvinceID: districtMap[subDistrict.DistrictID].ProvinceID,
+			Status:     CoordStatusMissing,
+		}
+
+		if builder := siblings[subDistrict.DistrictID]; builder != nil {
+			centroid := builder.extent().Centroid
+			lat, long := centroid.Lat, centroid.Long
+			subDistrict.Lat, subDistrict.Long = &lat, &long
+			subDistrict.CoordSource = CoordSourceEstimated
+
+			issue.Status = CoordSourceEstimated
+			issue.Lat, issue.Long = subDistrict.Lat, subDistrict.Long
+			issue.Siblings = builder.count
+			report.Estimated++
+		} else {
+			report.Missing++
+		}
+
+		report.Records = append(report.Records, issue)
+	}
+
+	report.Total = len(report.Records)
+	return report
+}
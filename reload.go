@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// dataFiles are the files a dataset is loaded from
+var dataFiles = []string{"geographies.json", "provinces.json", "districts.json", "sub_districts.json"}
+
+// status summarizes the dataset
+func (d *dataset) status() DatasetStatus {
+	return DatasetStatus{
+		LoadedAt:  d.loadedAt,
+		Version:   d.export.Version,
+		Checksums: d.checksums,
+		Counts: map[string]int{
+			"geographies":  len(d.geographies),
+			"provinces":    len(d.provinces),
+			"districts":    len(d.districts),
+			"subdistricts": len(d.subDistricts),
+		},
+	}
+}
+
+// validate checks that every level is present, IDs are unique and every
+// record refers to an existing parent
+func (d *dataset) validate() error {
+	if len(d.geographies) == 0 || len(d.provinces) == 0 || len(d.districts) == 0 || len(d.subDistricts) == 0 {
+		return fmt.Errorf("every level must contain at least one record")
+	}
+
+	geographyIDs := make(map[int]bool, len(d.geographies))
+	for _, geography := range d.geographies {
+		if geographyIDs[geography.ID] {
+			return fmt.Errorf("duplicate geography ID %d", geography.ID)
+		}
+		geographyIDs[geography.ID] = true
+	}
+
+	provinceIDs := make(map[int]bool, len(d.provinces))
+	for _, province := range d.provinces {
+		if provinceIDs[province.ID] {
+			return fmt.Errorf("duplicate province ID %d", province.ID)
+		}
+		if !geographyIDs[province.GeographyID] {
+			return fmt.Errorf("province %d refers to unknown geography %d", province.ID, province.GeographyID)
+		}
+		provinceIDs[province.ID] = true
+	}
+
+	districtIDs := make(map[int]bool, len(d.districts))
+	for _, district := range d.districts {
+		if districtIDs[district.ID] {
+			return fmt.Errorf("duplicate district ID %d", district.ID)
+		}
+		if !provinceIDs[district.ProvinceID] {
+			return fmt.Errorf("district %d refers to unknown province %d", district.ID, district.ProvinceID)
+		}
+		districtIDs[district.ID] = true
+	}
+
+	subDistrictIDs := make(map[int]bool, len(d.subDistricts))
+	for _, subDistrict := range d.subDistricts {
+		if subDistrictIDs[subDistrict.ID] {
+			return fmt.Errorf("duplicate sub-district ID %d", subDistrict.ID)
+		}
+		if !districtIDs[subDistrict.DistrictID] {
+			return fmt.Errorf("sub-district %d refers to unknown district %d", subDistrict.ID, subDistrict.DistrictID)
+		}
+		subDistrictIDs[subDistrict.ID] = true
+	}
+
+	return nil
+}
+
+// dataFingerprint identifies the current contents of the data files by their
+// sizes and modification times
+func dataFingerprint(dataPath string) string {
+	var sb strings.Builder
+	for _, name := range dataFiles {
+		info, err := os.Stat(filepath.Join(dataPath, name))
+		if err != nil {
+			sb.WriteString(name + ":missing;")
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
+
+// checksum returns the hex SHA-256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hasDataFiles reports whether a directory contains every data file
+func hasDataFiles(dir string) bool {
+	for _, name := range dataFiles {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// reload reloads a version, loading it for the first time if it is new, and
+// logs the outcome
+func (v *DatasetVersions) reload(name, trigger string) error {
+	ds, exists := v.Get(name)
+	if !exists {
+		loaded, err := NewDataService(filepath.Join(v.dataDir, name))
+		if err != nil {
+			log.Printf("Loading new dataset version %s on %s failed: %v", name, trigger, err)
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		v.add(name, loaded)
+		log.Printf("Loaded new dataset version %s on %s", name, trigger)
+		return nil
+	}
+
+	status, err := ds.Reload()
+	if err != nil {
+		log.Printf("Reloading dataset version %s on %s failed, still serving the previous data: %v", name, trigger, err)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	log.Printf("Reloaded dataset version %s on %s: version %s, %d sub-districts", name, trigger, status.Version, status.Counts["subdistricts"])
+	return nil
+}
+
+// WatchDataFiles polls the data directory and reloads versions whose files
+// change, loading new version directories as they appear. A change is applied
+// only once the files have stayed the same for a whole interval, so a
+// partially copied dataset is not picked up. Ticks that find another reload
+// running are skipped, so their changes are applied on a later tick.
+func (v *DatasetVersions) WatchDataFiles(interval time.Duration) {
+	applied := make(map[string]string)
+	for _, name := range v.discover() {
+		applied[name] = dataFingerprint(filepath.Join(v.dataDir, name))
+	}
+	seen := maps.Clone(applied)
+
+	for range time.Tick(interval) {
+		if !v.reloading.TryLock() {
+			continue
+		}
+		for _, name := range v.discover() {
+			current := dataFingerprint(filepath.Join(v.dataDir, name))
+			if current != seen[name] {
+				seen[name] = current
+				continue
+			}
+			if current != applied[name] {
+				applied[name] = current
+				v.reload(name, "data file change")
+			}
+		}
+		v.reloading.Unlock()
+	}
+}
+
+// ReloadOnSignal reloads every version whenever the process receives SIGHUP
+func (v *DatasetVersions) ReloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		if err := v.ReloadAll("SIGHUP"); errors.Is(err, errReloadInProgress) {
+			log.Printf("Ignoring SIGHUP: %v", err)
+		}
+	}
+}
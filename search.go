@@ -0,0 +1,172 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Location levels used by suggestions and indexes
+const (
+	LevelProvince    = "province"
+	LevelDistrict    = "district"
+	LevelSubDistrict = "subdistrict"
+)
+
+// levelRank orders suggestion levels from broadest to narrowest
+var levelRank = map[string]int{
+	LevelProvince:    0,
+	LevelDistrict:    1,
+	LevelSubDistrict: 2,
+}
+
+// nameRef points at an indexed entity
+type nameRef struct {
+	level string
+	id    int
+}
+
+// nameSuffix is one rune-aligned suffix of an indexed name; length is the
+// rune count of the whole name
+type nameSuffix struct {
+	text   string
+	ref    nameRef
+	offset int
+	length int
+}
+
+// nameIndex is a sorted suffix index over normalized names. A lookup finds
+// every name containing the query, and suffixes at offset 0 are prefix matches.
+type nameIndex struct {
+	suffixes    []nameSuffix
+	ignoreTones bool
+}
+
+// nameMatch is a single entity matched by the index
+type nameMatch struct {
+	ref    nameRef
+	exact  bool
+	prefix bool
+	length int
+}
+
+// newNameIndex creates an empty name index, optionally ignoring tone marks
+// in both names and queries
+func newNameIndex(ignoreTones bool) *nameIndex {
+	return &nameIndex{ignoreTones: ignoreTones}
+}
+
+// add indexes every suffix of a name for the given entity
+func (idx *nameIndex) add(ref nameRef, name string) {
+	key := normalizeName(name, ref.level, idx.ignoreTones)
+	length := utf8.RuneCountInString(key)
+	for offset := range key {
+		idx.suffixes = append(idx.suffixes, nameSuffix{
+			text:   key[offset:],
+			ref:    ref,
+			offset: offset,
+			length: length,
+		})
+	}
+}
+
+// build sorts the suffixes; it must be called after all names are added
+func (idx *nameIndex) build() {
+	sort.Slice(idx.suffixes, func(i, j int) bool {
+		return idx.suffixes[i].text < idx.suffixes[j].text
+	})
+}
+
+// lookup returns every entity whose name contains the query, one match per entity
+func (idx *nameIndex) lookup(query string) []nameMatch {
+	query = normalizeSearchText(query, idx.ignoreTones)
+	if query == "" {
+		return nil
+	}
+
+	start := sort.Search(len(idx.suffixes), func(i int) bool {
+		return idx.suffixes[i].text >= query
+	})
+
+	best := make(map[nameRef]nameMatch)
+	for i := start; i < len(idx.suffixes) && strings.HasPrefix(idx.suffixes[i].text, query); i++ {
+		suffix := idx.suffixes[i]
+		match := nameMatch{
+			ref:    suffix.ref,
+			exact:  suffix.offset == 0 && len(suffix.text) == len(query),
+			prefix: suffix.offset == 0,
+			length: suffix.length,
+		}
+		if existing, ok := best[suffix.ref]; ok && !betterMatch(match, existing) {
+			continue
+		}
+		best[suffix.ref] = match
+	}
+
+	matches := make([]nameMatch, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+
+	// Exact and prefix matches first, then broader levels, shorter names and lower IDs
+	sort.Slice(matches, func(i, j int) bool {
+		return betterMatch(matches[i], matches[j])
+	})
+
+	return matches
+}
+
+// betterMatch reports whether a should rank above b
+func betterMatch(a, b nameMatch) bool {
+	if a.exact != b.exact {
+		return a.exact
+	}
+	if a.prefix != b.prefix {
+		return a.prefix
+	}
+	if levelRank[a.ref.level] != levelRank[b.ref.level] {
+		return levelRank[a.ref.level] < levelRank[b.ref.level]
+	}
+	if a.length != b.length {
+		return a.length < b.length
+	}
+	return a.ref.id < b.ref.id
+}
+
+// displayNameTH formats a Thai address label such as "ต.สีลม อ.บางรัก จ.กรุงเทพมหานคร 10500"
+func displayNameTH(subDistrict *SubDistrict, district *District, province *Province) string {
+	parts := make([]string, 0, 4)
+	if subDistrict != nil {
+		parts = append(parts, "ต."+subDistrict.NameTH)
+	}
+	if district != nil {
+		parts = append(parts, "อ."+strings.TrimPrefix(district.NameTH, "เขต"))
+	}
+	if province != nil {
+		parts = append(parts, "จ."+province.NameTH)
+	}
+	if subDistrict != nil && subDistrict.ZipCode != 0 {
+		parts = append(parts, strconv.Itoa(subDistrict.ZipCode))
+	}
+	return strings.Join(parts, " ")
+}
+
+// displayNameEN formats an English address label such as "Si Lom, Bang Rak, Bangkok 10500"
+func displayNameEN(subDistrict *SubDistrict, district *District, province *Province) string {
+	parts := make([]string, 0, 3)
+	if subDistrict != nil {
+		parts = append(parts, subDistrict.NameEN)
+	}
+	if district != nil {
+		parts = append(parts, strings.TrimPrefix(district.NameEN, "Khet "))
+	}
+	if province != nil {
+		parts = append(parts, province.NameEN)
+	}
+	label := strings.Join(parts, ", ")
+	if subDistrict != nil && subDistrict.ZipCode != 0 {
+		label += " " + strconv.Itoa(subDistrict.ZipCode)
+	}
+	return label
+}
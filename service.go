@@ -6,11 +6,24 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 // DataService handles loading and providing access to geographic data
 type DataService struct {
-	mu            sync.RWMutex
+	mu       sync.RWMutex
+	dataPath string
+	
+	// The current dataset; replaced as a whole on reload
+	*dataset
+	
+	// reloadMu serializes reloads so only one new dataset is built at a time
+	reloadMu sync.Mutex
+}
+
+// dataset is a fully indexed copy of the data files. It is never modified
+// after it has been built, so a reload swaps in a new one.
+type dataset struct {
 	geographies   []Geography
 	provinces     []Province
 	districts     []District
@@ -26,141 +39,277 @@ type DataService struct {
 	provincesByGeography    map[int][]Province
 	districtsByProvince     map[int][]District
 	subDistrictsByDistrict  map[int][]SubDistrict
+	subDistrictsByZipCode   map[int][]SubDistrict
+	
+	// Spatial index over sub-districts with coordinates
+	subDistrictGrid *spatialGrid
+	
+	// Sub-districts with missing or estimated coordinates
+	coordinateReport CoordinateReport
+	
+	// Centroids and bounding boxes computed from sub-district coordinates
+	districtExtents  map[int]*Extent
+	provinceExtents  map[int]*Extent
+	geographyExtents map[int]*Extent
+	
+	// Name indexes over all levels for autocomplete, the second ignoring
+	// tone marks
+	names              *nameIndex
+	namesIgnoringTones *nameIndex
+	
+	// Pre-serialized compact exports, with and without timestamps
+	export           exportPayload
+	exportTimestamps exportPayload
+	
+	// Deletion times of retired records left out of the live dataset, shared
+	// by the live and full datasets, and the dataset including them (the
+	// dataset itself if nothing was deleted)
+	deleted            map[nameRef]time.Time
+	withDeletedRecords *dataset
+	
+	// When the dataset was loaded and the SHA-256 of each data file
+	loadedAt  time.Time
+	checksums map[string]string
 }
 
 // NewDataService creates a new DataService and loads data from JSON files
 func NewDataService(dataPath string) (*DataService, error) {
-	ds := &DataService{
-		geographyMap:            make(map[int]Geography),
-		provinceMap:             make(map[int]Province),
-		districtMap:             make(map[int]District),
-		subDistrictMap:          make(map[int]SubDistrict),
-		provincesByGeography:    make(map[int][]Province),
-		districtsByProvince:     make(map[int][]District),
-		subDistrictsByDistrict:  make(map[int][]SubDistrict),
-	}
-
-	if err := ds.loadData(dataPath); err != nil {
+	data, err := loadDataset(dataPath)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load data: %w", err)
 	}
 
-	return ds, nil
+	return &DataService{
+		dataPath: dataPath,
+		dataset:  data,
+	}, nil
 }
 
-// loadData loads all geographic data from JSON files
-func (ds *DataService) loadData(dataPath string) error {
+// Reload loads the data files into a new dataset and swaps it in. The current
+// dataset keeps being served if loading or validation fails.
+func (ds *DataService) Reload() (DatasetStatus, error) {
+	ds.reloadMu.Lock()
+	defer ds.reloadMu.Unlock()
+
+	data, err := loadDataset(ds.dataPath)
+	if err != nil {
+		return DatasetStatus{}, fmt.Errorf("failed to reload data: %w", err)
+	}
+
 	ds.mu.Lock()
-	defer ds.mu.Unlock()
+	ds.dataset = data
+	ds.mu.Unlock()
+
+	return data.status(), nil
+}
+
+// GetStatus describes the dataset currently being served
+func (ds *DataService) GetStatus() DatasetStatus {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.status()
+}
+
+// current returns the dataset being served
+func (ds *DataService) current() *dataset {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.dataset
+}
+
+// Snapshot returns a view of the current dataset that a reload does not
+// change, so a request making several lookups sees one consistent state
+func (ds *DataService) Snapshot() *DataService {
+	return &DataService{
+		dataPath: ds.dataPath,
+		dataset:  ds.current(),
+	}
+}
+
+// loadDataset loads, validates and indexes all geographic data from JSON files
+func loadDataset(dataPath string) (*dataset, error) {
+	d := newDataset(time.Now(), make(map[string]string))
 
 	// Load geographies
-	if err := ds.loadGeographies(filepath.Join(dataPath, "geographies.json")); err != nil {
-		return fmt.Errorf("failed to load geographies: %w", err)
+	if err := d.loadGeographies(filepath.Join(dataPath, "geographies.json")); err != nil {
+		return nil, fmt.Errorf("failed to load geographies: %w", err)
 	}
 
 	// Load provinces
-	if err := ds.loadProvinces(filepath.Join(dataPath, "provinces.json")); err != nil {
-		return fmt.Errorf("failed to load provinces: %w", err)
+	if err := d.loadProvinces(filepath.Join(dataPath, "provinces.json")); err != nil {
+		return nil, fmt.Errorf("failed to load provinces: %w", err)
 	}
 
 	// Load districts
-	if err := ds.loadDistricts(filepath.Join(dataPath, "districts.json")); err != nil {
-		return fmt.Errorf("failed to load districts: %w", err)
+	if err := d.loadDistricts(filepath.Join(dataPath, "districts.json")); err != nil {
+		return nil, fmt.Errorf("failed to load districts: %w", err)
 	}
 
 	// Load sub-districts
-	if err := ds.loadSubDistricts(filepath.Join(dataPath, "sub_districts.json")); err != nil {
-		return fmt.Errorf("failed to load sub-districts: %w", err)
+	if err := d.loadSubDistricts(filepath.Join(dataPath, "sub_districts.json")); err != nil {
+		return nil, fmt.Errorf("failed to load sub-districts: %w", err)
 	}
 
-	// Build indexes
-	ds.buildIndexes()
+	// Reject incomplete or inconsistent data before indexing it
+	if err := d.validate(); err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
 
-	return nil
+	// Index every record for include_deleted, and only live records by
+	// default. Live records are split out first because indexing estimates
+	// missing coordinates in place, and live estimates must come from live
+	// siblings only.
+	live := d.withoutDeleted()
+	d.buildIndexes()
+	if live != d {
+		live.buildIndexes()
+	}
+
+	return live, nil
+}
+
+// newDataset creates an empty dataset
+func newDataset(loadedAt time.Time, checksums map[string]string) *dataset {
+	return &dataset{
+		geographyMap:            make(map[int]Geography),
+		provinceMap:             make(map[int]Province),
+		districtMap:             make(map[int]District),
+		subDistrictMap:          make(map[int]SubDistrict),
+		provincesByGeography:    make(map[int][]Province),
+		districtsByProvince:     make(map[int][]District),
+		subDistrictsByDistrict:  make(map[int][]SubDistrict),
+		subDistrictsByZipCode:   make(map[int][]SubDistrict),
+		deleted:                 make(map[nameRef]time.Time),
+		loadedAt:                loadedAt,
+		checksums:               checksums,
+	}
 }
 
 // loadGeographies loads geography data from JSON file
-func (ds *DataService) loadGeographies(filePath string) error {
+func (d *dataset) loadGeographies(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(data, &ds.geographies); err != nil {
+	if err := json.Unmarshal(data, &d.geographies); err != nil {
 		return err
 	}
+	d.checksums[filepath.Base(filePath)] = checksum(data)
 
 	return nil
 }
 
 // loadProvinces loads province data from JSON file
-func (ds *DataService) loadProvinces(filePath string) error {
+func (d *dataset) loadProvinces(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(data, &ds.provinces); err != nil {
+	if err := json.Unmarshal(data, &d.provinces); err != nil {
 		return err
 	}
+	d.checksums[filepath.Base(filePath)] = checksum(data)
 
 	return nil
 }
 
 // loadDistricts loads district data from JSON file
-func (ds *DataService) loadDistricts(filePath string) error {
+func (d *dataset) loadDistricts(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(data, &ds.districts); err != nil {
+	if err := json.Unmarshal(data, &d.districts); err != nil {
 		return err
 	}
+	d.checksums[filepath.Base(filePath)] = checksum(data)
 
 	return nil
 }
 
 // loadSubDistricts loads sub-district data from JSON file
-func (ds *DataService) loadSubDistricts(filePath string) error {
+func (d *dataset) loadSubDistricts(filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(data, &ds.subDistricts); err != nil {
+	if err := json.Unmarshal(data, &d.subDistricts); err != nil {
 		return err
 	}
+	d.checksums[filepath.Base(filePath)] = checksum(data)
 
 	return nil
 }
 
 // buildIndexes creates index maps for fast lookups and relationships
-func (ds *DataService) buildIndexes() {
+func (d *dataset) buildIndexes() {
 	// Build geography map
-	for _, geography := range ds.geographies {
-		ds.geographyMap[geography.ID] = geography
+	for _, geography := range d.geographies {
+		d.geographyMap[geography.ID] = geography
 	}
 
 	// Build province map and geography relationships
-	for _, province := range ds.provinces {
-		ds.provinceMap[province.ID] = province
-		ds.provincesByGeography[province.GeographyID] = append(
-			ds.provincesByGeography[province.GeographyID], province)
+	for _, province := range d.provinces {
+		d.provinceMap[province.ID] = province
+		d.provincesByGeography[province.GeographyID] = append(
+			d.provincesByGeography[province.GeographyID], province)
 	}
 
 	// Build district map and province relationships
-	for _, district := range ds.districts {
-		ds.districtMap[district.ID] = district
-		ds.districtsByProvince[district.ProvinceID] = append(
-			ds.districtsByProvince[district.ProvinceID], district)
+	for _, district := range d.districts {
+		d.districtMap[district.ID] = district
+		d.districtsByProvince[district.ProvinceID] = append(
+			d.districtsByProvince[district.ProvinceID], district)
 	}
 
-	// Build sub-district map and district relationships
-	for _, subDistrict := range ds.subDistricts {
-		ds.subDistrictMap[subDistrict.ID] = subDistrict
-		ds.subDistrictsByDistrict[subDistrict.DistrictID] = append(
-			ds.subDistrictsByDistrict[subDistrict.DistrictID], subDistrict)
+	// Estimate missing sub-district coordinates before any geo index is built
+	d.coordinateReport = imputeCoordinates(d.subDistricts, d.districtMap)
+
+	// Build sub-district map, district relationships and zip code index
+	for _, subDistrict := range d.subDistricts {
+		d.subDistrictMap[subDistrict.ID] = subDistrict
+		d.subDistrictsByDistrict[subDistrict.DistrictID] = append(
+			d.subDistrictsByDistrict[subDistrict.DistrictID], subDistrict)
+		d.subDistrictsByZipCode[subDistrict.ZipCode] = append(
+			d.subDistrictsByZipCode[subDistrict.ZipCode], subDistrict)
+	}
+
+	// Build spatial index, skipping sub-districts without coordinates
+	d.subDistrictGrid = newSpatialGrid(d.subDistricts)
+
+	// Build extents of every level above sub-districts
+	d.districtExtents, d.provinceExtents, d.geographyExtents = buildExtents(
+		d.subDistricts, d.districtMap, d.provinceMap)
+
+	// Build name indexes across provinces, districts and sub-districts, with
+	// and without tone marks
+	d.names = newNameIndex(false)
+	d.namesIgnoringTones = newNameIndex(true)
+	addNames := func(ref nameRef, nameTH, nameEN string) {
+		for _, names := range []*nameIndex{d.names, d.namesIgnoringTones} {
+			names.add(ref, nameTH)
+			names.add(ref, nameEN)
+		}
+	}
+	for _, province := range d.provinces {
+		addNames(nameRef{level: LevelProvince, id: province.ID}, province.NameTH, province.NameEN)
 	}
+	for _, district := range d.districts {
+		addNames(nameRef{level: LevelDistrict, id: district.ID}, district.NameTH, district.NameEN)
+	}
+	for _, subDistrict := range d.subDistricts {
+		addNames(nameRef{level: LevelSubDistrict, id: subDistrict.ID}, subDistrict.NameTH, subDistrict.NameEN)
+	}
+	d.names.build()
+	d.namesIgnoringTones.build()
+
+	// Build compact exports
+	d.export = buildExport(d.geographies, d.provinces, d.districts, d.subDistricts, false)
+	d.exportTimestamps = buildExport(d.geographies, d.provinces, d.districts, d.subDistricts, true)
 }
 
 // GetGeographies returns all geographies
@@ -242,4 +391,173 @@ func (ds *DataService) GetSubDistrictsByDistrict(districtID int) []SubDistrict {
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 	return ds.subDistrictsByDistrict[districtID]
+}
+
+// GetProvinceTree returns a province with its districts (depth 1) and their
+// sub-districts (depth 2)
+func (ds *DataService) GetProvinceTree(id, depth int) (ProvinceTree, bool) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	province, exists := ds.provinceMap[id]
+	if !exists {
+		return ProvinceTree{}, false
+	}
+
+	tree := ProvinceTree{Province: province}
+	if depth < 1 {
+		return tree, true
+	}
+
+	districts := ds.districtsByProvince[id]
+	tree.Districts = make([]DistrictTree, 0, len(districts))
+	for _, district := range districts {
+		node := DistrictTree{District: district}
+		if depth >= 2 {
+			node.SubDistricts = ds.subDistrictsByDistrict[district.ID]
+		}
+		tree.Districts = append(tree.Districts, node)
+	}
+
+	return tree, true
+}
+
+// GetDistrictExtent returns the computed extent of a district, or nil if
+// none of its sub-districts have coordinates
+func (ds *DataService) GetDistrictExtent(id int) *Extent {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.districtExtents[id]
+}
+
+// GetProvinceExtent returns the computed extent of a province
+func (ds *DataService) GetProvinceExtent(id int) *Extent {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.provinceExtents[id]
+}
+
+// GetGeographyExtent returns the computed extent of a geography
+func (ds *DataService) GetGeographyExtent(id int) *Extent {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.geographyExtents[id]
+}
+
+// GetCoordinateReport returns the sub-districts with missing or estimated coordinates
+func (ds *DataService) GetCoordinateReport() CoordinateReport {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.coordinateReport
+}
+
+// GetSubDistrictsByZipCode returns sub-districts by zip code
+func (ds *DataService) GetSubDistrictsByZipCode(zipCode int) []SubDistrict {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.subDistrictsByZipCode[zipCode]
+}
+
+// GetNearestSubDistrict returns the sub-district closest to a coordinate and its distance in meters
+func (ds *DataService) GetNearestSubDistrict(lat, long float64) (SubDistrict, float64, bool) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.subDistrictGrid.nearest(lat, long)
+}
+
+// GetSubDistrictsNearby returns sub-districts within radius meters of a coordinate, nearest first
+func (ds *DataService) GetSubDistrictsNearby(lat, long, radius float64) []SubDistrictWithDistance {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.subDistrictGrid.withinRadius(lat, long, radius)
+}
+
+// GetSubDistrictsWithin returns sub-districts inside a bounding box, nearest to its center first
+func (ds *DataService) GetSubDistrictsWithin(minLong, minLat, maxLong, maxLat float64) []SubDistrictWithDistance {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.subDistrictGrid.withinBounds(minLong, minLat, maxLong, maxLat)
+}
+
+// Autocomplete returns up to limit suggestions whose Thai or English name contains the query
+func (ds *DataService) Autocomplete(query string, limit int, ignoreTones bool) []Suggestion {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	names := ds.names
+	if ignoreTones {
+		names = ds.namesIgnoringTones
+	}
+	matches := names.lookup(query)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	suggestions := make([]Suggestion, 0, len(matches))
+	for _, match := range matches {
+		suggestions = append(suggestions, ds.suggestionFor(match))
+	}
+	return suggestions
+}
+
+// suggestionFor resolves a name match into a suggestion; callers must hold the read lock
+func (ds *DataService) suggestionFor(match nameMatch) Suggestion {
+	suggestion := Suggestion{
+		Level:  match.ref.level,
+		ID:     match.ref.id,
+		Prefix: match.prefix,
+	}
+
+	switch match.ref.level {
+	case LevelProvince:
+		province := ds.provinceMap[match.ref.id]
+		suggestion.NameTH = province.NameTH
+		suggestion.NameEN = province.NameEN
+		suggestion.Display = displayNameTH(nil, nil, &province)
+		suggestion.DisplayEN = displayNameEN(nil, nil, &province)
+	case LevelDistrict:
+		district := ds.districtMap[match.ref.id]
+		province := ds.provinceMap[district.ProvinceID]
+		suggestion.NameTH = district.NameTH
+		suggestion.NameEN = district.NameEN
+		suggestion.Display = displayNameTH(nil, &district, &province)
+		suggestion.DisplayEN = displayNameEN(nil, &district, &province)
+	case LevelSubDistrict:
+		subDistrict := ds.subDistrictMap[match.ref.id]
+		district := ds.districtMap[subDistrict.DistrictID]
+		province := ds.provinceMap[district.ProvinceID]
+		suggestion.NameTH = subDistrict.NameTH
+		suggestion.NameEN = subDistrict.NameEN
+		suggestion.ZipCode = subDistrict.ZipCode
+		suggestion.Display = displayNameTH(&subDistrict, &district, &province)
+		suggestion.DisplayEN = displayNameEN(&subDistrict, &district, &province)
+	}
+
+	return suggestion
+}
+
+// GetExport returns the pre-serialized compact export of the full dataset
+func (ds *DataService) GetExport(withTimestamps bool) exportPayload {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	if withTimestamps {
+		return ds.exportTimestamps
+	}
+	return ds.export
+}
+
+// GetExportVersion returns the version and record counts of the compact export
+func (ds *DataService) GetExportVersion() ExportVersion {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ExportVersion{
+		Format:  exportFormatVersion,
+		Version: ds.export.Version,
+		Counts: map[string]int{
+			"geographies":  len(ds.geographies),
+			"provinces":    len(ds.provinces),
+			"districts":    len(ds.districts),
+			"subdistricts": len(ds.subDistricts),
+		},
+	}
 }
\ No newline at end of file
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sortable fields per list type
+var (
+	provinceSortFields    = map[string]bool{"id": true, "name_th": true, "name_en": true, "geography_id": true}
+	districtSortFields    = map[string]bool{"id": true, "name_th": true, "name_en": true, "province_id": true}
+	subDistrictSortFields = map[string]bool{"id": true, "name_th": true, "name_en": true, "district_id": true, "zip_code": true}
+)
+
+// sortKey is one field of a multi-key sort, e.g. "-name_en"
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// sortable is implemented by list items that can be ordered by field name
+type sortable interface {
+	keyed
+	sortValue(field string) sortValue
+}
+
+// sortValue is a comparable field value; text is used when set, else num
+type sortValue struct {
+	text string
+	num  int
+}
+
+// compare orders two sort values
+func (a sortValue) compare(b sortValue) int {
+	if c := strings.Compare(a.text, b.text); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.num, b.num)
+}
+
+func (p Province) sortValue(field string) sortValue {
+	switch field {
+	case "name_th":
+		return sortValue{text: thaiCollationKey(p.NameTH)}
+	case "name_en":
+		return sortValue{text: strings.ToLower(p.NameEN)}
+	case "geography_id":
+		return sortValue{num: p.GeographyID}
+	}
+	return sortValue{num: p.ID}
+}
+
+func (d District) sortValue(field string) sortValue {
+	switch field {
+	case "name_th":
+		return sortValue{text: thaiCollationKey(d.NameTH)}
+	case "name_en":
+		return sortValue{text: strings.ToLower(d.NameEN)}
+	case "province_id":
+		return sortValue{num: d.ProvinceID}
+	}
+	return sortValue{num: d.ID}
+}
+
+func (s SubDistrict) sortValue(field string) sortValue {
+	switch field {
+	case "name_th":
+		return sortValue{text: thaiCollationKey(s.NameTH)}
+	case "name_en":
+		return sortValue{text: strings.ToLower(s.NameEN)}
+	case "district_id":
+		return sortValue{num: s.DistrictID}
+	case "zip_code":
+		return sortValue{num: s.ZipCode}
+	}
+	return sortValue{num: s.ID}
+}
+
+// parseSort parses a comma-separated sort parameter such as "zip_code,-name_en"
+func parseSort(param string, allowed map[string]bool) ([]sortKey, error) {
+	keys := make([]sortKey, 0)
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := sortKey{field: part}
+		if strings.HasPrefix(part, "-") {
+			key = sortKey{field: part[1:], desc: true}
+		}
+		if !allowed[key.field] {
+			return nil, fmt.Errorf("Invalid sort field: %s", key.field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// applySort returns a sorted copy of the items. Ties are broken by ID so the
+// order is stable across pages.
+func applySort[T sortable](items []T, param string, allowed map[string]bool) ([]T, error) {
+	return sortItems(items, param, allowed, func(a, b T) int {
+		return cmp.Compare(a.key(), b.key())
+	})
+}
+
+// applyRankedSort returns a sorted copy of ranked items, such as fuzzy search
+// hits best first. Ties keep their rank, so the rank remains the last sort key.
+func applyRankedSort[T sortable](items []T, param string, allowed map[string]bool) ([]T, error) {
+	return sortItems(items, param, allowed, func(a, b T) int {
+		return 0
+	})
+}
+
+// sortItems returns a stably sorted copy of the items, breaking ties with tie
+func sortItems[T sortable](items []T, param string, allowed map[string]bool, tie func(a, b T) int) ([]T, error) {
+	keys, err := parseSort(param, allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Precompute sort values since Thai collation keys are costly
+	values := make(map[int][]sortValue, len(items))
+	for _, item := range items {
+		itemValues := make([]sortValue, len(keys))
+		for i, key := range keys {
+			itemValues[i] = item.sortValue(key.field)
+		}
+		values[item.key()] = itemValues
+	}
+
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		va, vb := values[a.key()], values[b.key()]
+		for i, key := range keys {
+			c := va[i].compare(vb[i])
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return tie(a, b)
+	})
+	return sorted, nil
+}
+
+// isThaiLeadingVowel reports whether r is a vowel written before its consonant (เ แ โ ใ ไ)
+func isThaiLeadingVowel(r rune) bool {
+	return r >= 'เ' && r <= 'ไ'
+}
+
+// isThaiMark reports whether r is a tone mark or diacritic that only matters
+// as a tie-breaker in dictionary order (mai taikhu to yamakkan)
+func isThaiMark(r rune) bool {
+	return r >= '็' && r <= '๎'
+}
+
+// thaiCollationKey returns a key whose byte order follows Thai dictionary
+// order: leading vowels sort after the consonant they precede, and tone marks
+// are compared only when the remaining letters are equal
+func thaiCollationKey(s string) string {
+	runes := []rune(s)
+	primary := make([]rune, 0, len(runes))
+	marks := make([]rune, 0)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isThaiLeadingVowel(r) && i+1 < len(runes):
+			primary = append(primary, runes[i+1], r)
+			i++
+		case isThaiMark(r):
+			marks = append(marks, r)
+		default:
+			primary = append(primary, r)
+		}
+	}
+
+	return string(primary) + "\x00" + string(marks)
+}
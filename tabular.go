@@ -0,0 +1,246 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Alternative output formats selectable with the format parameter
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatGeoJSON = "geojson"
+
+	mimeCSV     = "text/csv; charset=utf-8"
+	mimeXLSX    = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimeGeoJSON = "application/geo+json"
+)
+
+// formatAcceptTypes maps each output format to the Accept media type that selects it
+var formatAcceptTypes = map[string]string{
+	FormatCSV:     "text/csv",
+	FormatXLSX:    mimeXLSX,
+	FormatGeoJSON: mimeGeoJSON,
+}
+
+// utf8BOM lets Excel detect UTF-8 so Thai text is not garbled
+const utf8BOM = "\xef\xbb\xbf"
+
+// table is a header row and data rows; cells are strings or ints
+type table struct {
+	header []string
+	rows   [][]interface{}
+}
+
+// outputFormat returns the requested output format from the format query
+// parameter or, failing that, the Accept header; "" means JSON. Only the
+// given formats are accepted.
+func outputFormat(c *fiber.Ctx, formats ...string) (string, error) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		accept := c.Get(fiber.HeaderAccept)
+		for _, candidate := range formats {
+			if strings.Contains(accept, formatAcceptTypes[candidate]) {
+				return candidate, nil
+			}
+		}
+		return "", nil
+	}
+
+	if format == "json" {
+		return "", nil
+	}
+	if slices.Contains(formats, format) {
+		return format, nil
+	}
+	return "", fmt.Errorf("Invalid format parameter: %s", format)
+}
+
+// sendTable streams a table as a CSV or XLSX attachment
+func sendTable(c *fiber.Ctx, format, name string, t table) error {
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	if format == FormatXLSX {
+		c.Set(fiber.HeaderContentType, mimeXLSX)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			writeXLSX(w, name, t)
+		})
+		return nil
+	}
+
+	c.Set(fiber.HeaderContentType, mimeCSV)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writeCSV(w, t)
+	})
+	return nil
+}
+
+// writeCSV writes a table as CSV with a UTF-8 byte order mark
+func writeCSV(w *bufio.Writer, t table) {
+	w.WriteString(utf8BOM)
+
+	writer := csv.NewWriter(w)
+	writer.Write(t.header)
+	record := make([]string, len(t.header))
+	for _, row := range t.rows {
+		for i, cell := range row {
+			record[i] = cellText(cell)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
+
+// cellText formats a cell for CSV output
+func cellText(cell interface{}) string {
+	switch v := cell.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(cell)
+}
+
+// writeXLSX writes a table as a single-sheet Office Open XML workbook
+func writeXLSX(w *bufio.Writer, sheetName string, t table) {
+	archive := zip.NewWriter(w)
+	defer archive.Close()
+
+	writeZipFile(archive, "[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`)
+	writeZipFile(archive, "_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`)
+	writeZipFile(archive, "xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`+xmlEscape(sheetName)+`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+	writeZipFile(archive, "xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`)
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return
+	}
+
+	var sb strings.Builder
+	sheet.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`))
+
+	writeRow := func(cells []interface{}) {
+		sb.Reset()
+		sb.WriteString("<row>")
+		for _, cell := range cells {
+			switch v := cell.(type) {
+			case int:
+				sb.WriteString("<c><v>" + strconv.Itoa(v) + "</v></c>")
+			case *float64:
+				if v == nil {
+					sb.WriteString("<c/>")
+				} else {
+					sb.WriteString("<c><v>" + strconv.FormatFloat(*v, 'f', -1, 64) + "</v></c>")
+				}
+			default:
+				sb.WriteString(`<c t="inlineStr"><is><t>` + xmlEscape(cellText(cell)) + "</t></is></c>")
+			}
+		}
+		sb.WriteString("</row>")
+		sheet.Write([]byte(sb.String()))
+	}
+
+	header := make([]interface{}, len(t.header))
+	for i, name := range t.header {
+		header[i] = name
+	}
+	writeRow(header)
+	for _, row := range t.rows {
+		writeRow(row)
+	}
+
+	sheet.Write([]byte(`</sheetData></worksheet>`))
+}
+
+// writeZipFile adds a small file to a zip archive
+func writeZipFile(archive *zip.Writer, name, content string) {
+	if f, err := archive.Create(name); err == nil {
+		f.Write([]byte(content))
+	}
+}
+
+// xmlEscape escapes text for XML content and attributes
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// provinceTable builds a table of provinces, optionally with the geography name
+func (h *LocationHandler) provinceTable(provinces []Province, parents bool) table {
+	t := table{header: []string{"id", "name_th", "name_en", "geography_id"}}
+	if parents {
+		t.header = append(t.header, "geography_name")
+	}
+
+	for _, province := range provinces {
+		row := []interface{}{province.ID, province.NameTH, province.NameEN, province.GeographyID}
+		if parents {
+			geography, _ := h.dataService.GetGeography(province.GeographyID)
+			row = append(row, geography.Name)
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
+
+// districtTable builds a table of districts, optionally with province names
+func (h *LocationHandler) districtTable(districts []District, parents bool) table {
+	t := table{header: []string{"id", "name_th", "name_en", "province_id"}}
+	if parents {
+		t.header = append(t.header, "province_name_th", "province_name_en")
+	}
+
+	for _, district := range districts {
+		row := []interface{}{district.ID, district.NameTH, district.NameEN, district.ProvinceID}
+		if parents {
+			province, _ := h.dataService.GetProvince(district.ProvinceID)
+			row = append(row, province.NameTH, province.NameEN)
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
+
+// subDistrictTable builds a table of sub-districts, optionally with district
+// and province names. coord_source is "estimated" for imputed coordinates and
+// empty for source ones.
+func (h *LocationHandler) subDistrictTable(subDistricts []SubDistrict, parents bool) table {
+	t := table{header: []string{"id", "zip_code", "name_th", "name_en", "district_id", "lat", "long", "coord_source"}}
+	if parents {
+		t.header = append(t.header, "district_name_th", "district_name_en", "province_id", "province_name_th", "province_name_en")
+	}
+
+	for _, subDistrict := range subDistricts {
+		row := []interface{}{
+			subDistrict.ID, subDistrict.ZipCode, subDistrict.NameTH, subDistrict.NameEN,
+			subDistrict.DistrictID, subDistrict.Lat, subDistrict.Long, subDistrict.CoordSource,
+		}
+		if parents {
+			district, _ := h.dataService.GetDistrict(subDistrict.DistrictID)
+			province, _ := h.dataService.GetProvince(district.ProvinceID)
+			row = append(row, district.NameTH, district.NameEN, province.ID, province.NameTH, province.NameEN)
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// errReloadInProgress is returned when a reload is requested while one runs
+var errReloadInProgress = errors.New("A reload is already in progress")
+
+// DatasetVersions holds every named dataset version, one per directory under
+// the data directory (e.g. data/raw, data/2024-01-01)
+type DatasetVersions struct {
+	mu             sync.RWMutex
+	dataDir        string
+	defaultVersion string
+	services       map[string]*DataService
+
+	// reloading is held while every version is being reloaded
+	reloading sync.Mutex
+}
+
+// NewDatasetVersions loads every version found under dataDir. The default
+// version must be among them.
+func NewDatasetVersions(dataDir, defaultVersion string) (*DatasetVersions, error) {
+	v := &DatasetVersions{
+		dataDir:        dataDir,
+		defaultVersion: defaultVersion,
+		services:       make(map[string]*DataService),
+	}
+
+	for _, name := range v.discover() {
+		ds, err := NewDataService(filepath.Join(dataDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("dataset version %s: %w", name, err)
+		}
+		v.services[name] = ds
+	}
+
+	if _, exists := v.services[defaultVersion]; !exists {
+		return nil, fmt.Errorf("default dataset version %s not found in %s", defaultVersion, dataDir)
+	}
+	return v, nil
+}
+
+// discover returns the names of the directories under the data directory
+// that contain a full set of data files, in sorted order
+func (v *DatasetVersions) discover() []string {
+	entries, err := os.ReadDir(v.dataDir)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && hasDataFiles(filepath.Join(v.dataDir, entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// add registers a newly loaded version
+func (v *DatasetVersions) add(name string, ds *DataService) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.services[name] = ds
+}
+
+// Get returns a version by name
+func (v *DatasetVersions) Get(name string) (*DataService, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	ds, exists := v.services[name]
+	return ds, exists
+}
+
+// Default returns the name of the version served when none is requested
+func (v *DatasetVersions) Default() string {
+	return v.defaultVersion
+}
+
+// List describes every loaded version, in name order
+func (v *DatasetVersions) List() []DatasetStatus {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	statuses := make([]DatasetStatus, 0, len(v.services))
+	for name, ds := range v.services {
+		status := ds.GetStatus()
+		status.Name = name
+		status.Default = name == v.defaultVersion
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// ReloadAll reloads every version and loads any new version directories.
+// Versions that fail to reload keep serving their previous data. A reload
+// requested while another runs is refused rather than queued.
+func (v *DatasetVersions) ReloadAll(trigger string) error {
+	if !v.reloading.TryLock() {
+		return errReloadInProgress
+	}
+	defer v.reloading.Unlock()
+
+	var errs []error
+	for _, name := range v.discover() {
+		if err := v.reload(name, trigger); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Diff compares two loaded versions
+func (v *DatasetVersions) Diff(from, to string) (DatasetDiff, error) {
+	fromService, exists := v.Get(from)
+	if !exists {
+		return DatasetDiff{}, fmt.Errorf("Dataset version not found: %s", from)
+	}
+	toService, exists := v.Get(to)
+	if !exists {
+		return DatasetDiff{}, fmt.Errorf("Dataset version not found: %s", to)
+	}
+	return diffDatasets(from, fromService.current(), to, toService.current()), nil
+}